@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/thomas-reed/gator/internal/database"
+)
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Subtitle  *atomText   `xml:"subtitle,omitempty"`
+	Updated   string      `xml:"updated"`
+	Author    *atomPerson `xml:"author,omitempty"`
+	Links     []atomLink  `xml:"link"`
+	Generator string      `xml:"generator,omitempty"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Source     *atomSource    `xml:"source,omitempty"`
+
+	// Media RSS - media:content isn't read here since it would clash with
+	// <content>, but YouTube and the like use media:group anyway
+	MediaThumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaGroups     []mediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+type atomSource struct {
+	ID    string     `xml:"id"`
+	Title string     `xml:"title"`
+	Links []atomLink `xml:"link"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+// renderUserAtom renders a user's timeline as an Atom document, attributing
+// each entry to the feed it came from.  selfURL is optional.
+func renderUserAtom(user database.User, posts []database.GetPostsForUserRow, selfURL string) ([]byte, error) {
+	feed := atomFeed{
+		ID:        "urn:uuid:" + user.ID.String(),
+		Title:     fmt.Sprintf("Feeds followed by %s", user.Name),
+		Author:    &atomPerson{Name: user.Name},
+		Links:     []atomLink{},
+		Generator: "gator",
+	}
+	if selfURL != "" {
+		feed.Links = append(feed.Links, atomLink{
+			Href: selfURL,
+			Rel:  "self",
+			Type: "application/atom+xml",
+		})
+	}
+
+	var updated time.Time
+	for _, post := range posts {
+		postTime := post.CreatedAt
+		if post.PublishedAt.Valid {
+			postTime = post.PublishedAt.Time
+		}
+		if postTime.After(updated) {
+			updated = postTime
+		}
+
+		entry := atomEntry{
+			ID:      "urn:uuid:" + post.ID.String(),
+			Title:   post.Title,
+			Updated: postTime.UTC().Format(time.RFC3339),
+			Links: []atomLink{
+				{Href: post.Url, Rel: "alternate"},
+			},
+			Source: &atomSource{
+				ID:    post.FeedUrl,
+				Title: post.FeedName,
+				Links: []atomLink{
+					{Href: post.FeedUrl, Rel: "self"},
+				},
+			},
+		}
+		if post.PublishedAt.Valid {
+			entry.Published = entry.Updated
+		}
+		if post.Description.Valid {
+			entry.Summary = &atomText{
+				Type: "html",
+				Body: post.Description.String,
+			}
+		}
+		if post.Content.Valid {
+			entry.Content = &atomText{
+				Type: "html",
+				Body: post.Content.String,
+			}
+		}
+		if post.ImageUrl.Valid {
+			entry.MediaThumbnails = []mediaThumbnail{{URL: post.ImageUrl.String}}
+		}
+		if post.CommentsUrl.Valid {
+			entry.Links = append(entry.Links, atomLink{Href: post.CommentsUrl.String, Rel: "replies"})
+		}
+		if post.Author.Valid {
+			entry.Author = &atomPerson{Name: post.Author.String}
+		}
+		for _, category := range post.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Error marshalling atom feed:\n%w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
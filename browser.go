@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// openBrowser opens rawURL in $BROWSER if it's set, or with the system's
+// default handler (xdg-open, open, ...) otherwise.  It doesn't wait for the
+// browser to exit.  Post links come from feeds, so anything but http(s) is
+// refused rather than handed to a protocol handler or read as an option.
+func openBrowser(rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Refusing to open %q - only http and https links are opened", rawURL)
+	}
+	link := u.String()
+	var cmd *exec.Cmd
+	if browser := os.Getenv("BROWSER"); browser != "" {
+		// $BROWSER may list fallbacks separated by colons, and may say where
+		// the URL goes with %s
+		args := strings.Fields(strings.Split(browser, ":")[0])
+		if len(args) == 0 {
+			return fmt.Errorf("$BROWSER is set but empty")
+		}
+		hasURL := false
+		for i, arg := range args {
+			if strings.Contains(arg, "%s") {
+				args[i] = strings.ReplaceAll(arg, "%s", link)
+				hasURL = true
+			}
+		}
+		if !hasURL {
+			args = append(args, link)
+		}
+		cmd = exec.Command(args[0], args[1:]...)
+	} else {
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", link)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+		default:
+			cmd = exec.Command("xdg-open", link)
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Error opening browser:\n%w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
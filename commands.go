@@ -2,12 +2,26 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
-	"strconv"
 
 	"github.com/thomas-reed/gator/internal/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const sessionDuration = 30 * 24 * time.Hour
+
+const (
+	roleAdmin  = "admin"
+	roleMember = "member"
 )
 
 type command struct {
@@ -31,6 +45,56 @@ func (c *commands) register(name string, f func(*state, command) error) {
 	c.registry[name] = f
 }
 
+// subcommands dispatches `gator <command> <subcommand> [args...]` to the
+// handler registered for the subcommand.
+func subcommands(handlers map[string]func(*state, command) error) func(*state, command) error {
+	return func(s *state, cmd command) error {
+		usage := fmt.Sprintf("gator %s <%s> [args...]", cmd.name, strings.Join(slices.Sorted(maps.Keys(handlers)), "|"))
+		if len(cmd.args) < 1 {
+			return fmt.Errorf("Subcommand required.  Usage: %s", usage)
+		}
+		name := strings.ToLower(cmd.args[0])
+		handler, found := handlers[name]
+		if !found {
+			return fmt.Errorf("Unknown subcommand '%s'.  Usage: %s", name, usage)
+		}
+		return handler(s, command{name: cmd.name + " " + name, args: cmd.args[1:]})
+	}
+}
+
+// parseFlags splits args into positional arguments and --name value (or
+// --name=value) flags.  Flags listed in boolFlags don't take a value.  Each
+// flag can only be given once, since only one value is kept.
+func parseFlags(args []string, boolFlags ...string) ([]string, map[string]string, error) {
+	positional := []string{}
+	flags := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		name, found := strings.CutPrefix(args[i], "--")
+		if !found || name == "" {
+			positional = append(positional, args[i])
+			continue
+		}
+		name, value, hasValue := strings.Cut(name, "=")
+		if _, repeated := flags[name]; repeated {
+			return nil, nil, fmt.Errorf("--%s given more than once", name)
+		}
+		if hasValue {
+			flags[name] = value
+			continue
+		}
+		if slices.Contains(boolFlags, name) {
+			flags[name] = "true"
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, nil, fmt.Errorf("Missing value for --%s", name)
+		}
+		flags[name] = args[i+1]
+		i++
+	}
+	return positional, flags, nil
+}
+
 func handlerLogin(s *state, cmd command) error {
 	if len(cmd.args) < 1 {
 		return fmt.Errorf("Username required.  Usage: gator %s <name>", cmd.name)
@@ -41,39 +105,216 @@ func handlerLogin(s *state, cmd command) error {
 		return fmt.Errorf("User '%s' not registered", username)
 	}
 
-	if err := s.cfg.SetUser(user.Name); err != nil {
-		return fmt.Errorf("Couldn't set user:\n%w", err)
+	if user.HashedPassword.Valid {
+		password, err := readPassword("Password: ")
+		if err != nil {
+			return fmt.Errorf("Couldn't read password:\n%w", err)
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword.String), []byte(password)); err != nil {
+			return fmt.Errorf("Incorrect password for user '%s'", username)
+		}
+	}
+
+	if err := startSession(s, user); err != nil {
+		return err
 	}
 	fmt.Printf("User %s set in config\n", username)
 	return nil
 }
 
+func handlerLogout(s *state, cmd command) error {
+	if s.cfg.SessionToken != "" {
+		if err := s.db.DeleteSession(context.Background(), hashToken(s.cfg.SessionToken)); err != nil {
+			return fmt.Errorf("Error deleting session:\n%w", err)
+		}
+	}
+	if err := s.cfg.SetUser("", ""); err != nil {
+		return fmt.Errorf("Couldn't clear user:\n%w", err)
+	}
+	fmt.Println("Logged out")
+	return nil
+}
+
 func handlerRegister(s *state, cmd command) error {
 	if len(cmd.args) < 1 {
 		return fmt.Errorf("Username required.  Usage: gator %s <name>", cmd.name)
 	}
 	name := strings.ToLower(cmd.args[0])
-	
-	user, err := s.db.CreateUser(context.Background(), name)
+	hashedPassword, err := promptNewPassword()
+	if err != nil {
+		return err
+	}
+
+	user, err := s.db.CreateUser(context.Background(), database.CreateUserParams{
+		Name:           name,
+		HashedPassword: hashedPassword,
+	})
 	if err != nil {
 		return fmt.Errorf("Couldn't create user:\n%w", err)
 	}
-	if err := s.cfg.SetUser(user.Name); err != nil {
-		return fmt.Errorf("Couldn't set user:\n%w", err)
+	if err := startSession(s, user); err != nil {
+		return err
 	}
 	fmt.Println("User created:")
 	fmt.Printf("Name: %s\n", user.Name)
 	fmt.Printf("ID: %s\n", user.ID)
+	fmt.Printf("Password protected: %t\n", user.HashedPassword.Valid)
 	fmt.Printf("Created At: %s\n", user.CreatedAt)
 	fmt.Printf("Updated At: %s\n", user.UpdatedAt)
 	return nil
 }
 
-func handlerReset(s *state, cmd command) error {
-	if err := s.db.ResetUsers(context.Background()); err != nil {
-		return fmt.Errorf("Error reseting user table:\n%w", err)
+func handlerPasswd(s *state, cmd command, user database.User) error {
+	if user.HashedPassword.Valid {
+		password, err := readPassword("Current password: ")
+		if err != nil {
+			return fmt.Errorf("Couldn't read password:\n%w", err)
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword.String), []byte(password)); err != nil {
+			return errors.New("Incorrect password")
+		}
+	}
+	hashedPassword, err := promptNewPassword()
+	if err != nil {
+		return err
+	}
+	if err := s.db.SetUserPassword(context.Background(), database.SetUserPasswordParams{
+		ID:             user.ID,
+		HashedPassword: hashedPassword,
+	}); err != nil {
+		return fmt.Errorf("Error updating password:\n%w", err)
+	}
+
+	// log out every other session, but keep this one going
+	if err := s.db.DeleteSessionsForUser(context.Background(), user.ID); err != nil {
+		return fmt.Errorf("Error deleting sessions:\n%w", err)
+	}
+	if err := startSession(s, user); err != nil {
+		return err
+	}
+	if hashedPassword.Valid {
+		fmt.Printf("Password updated for %s\n", user.Name)
+	} else {
+		fmt.Printf("Password removed for %s\n", user.Name)
 	}
-	fmt.Println("User table reset successfully")
+	return nil
+}
+
+// promptNewPassword asks for a password twice and returns its bcrypt hash,
+// or a NULL hash if the user leaves it blank.
+func promptNewPassword() (sql.NullString, error) {
+	password, err := readPassword("Password (leave blank for none): ")
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("Couldn't read password:\n%w", err)
+	}
+	if password == "" {
+		return sql.NullString{}, nil
+	}
+	confirm, err := readPassword("Confirm password: ")
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("Couldn't read password:\n%w", err)
+	}
+	if confirm != password {
+		return sql.NullString{}, errors.New("Passwords don't match")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("Couldn't hash password:\n%w", err)
+	}
+	return sql.NullString{String: string(hash), Valid: true}, nil
+}
+
+// startSession creates a db session for user and saves its token in the
+// config, replacing whatever session was there before.
+func startSession(s *state, user database.User) error {
+	if s.cfg.SessionToken != "" {
+		s.db.DeleteSession(context.Background(), hashToken(s.cfg.SessionToken))
+	}
+	token, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("Couldn't generate session token:\n%w", err)
+	}
+	if _, err := s.db.CreateSession(context.Background(), database.CreateSessionParams{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(sessionDuration),
+	}); err != nil {
+		return fmt.Errorf("Couldn't create session:\n%w", err)
+	}
+	if err := s.cfg.SetUser(user.Name, token); err != nil {
+		return fmt.Errorf("Couldn't set user:\n%w", err)
+	}
+	return nil
+}
+
+func handlerReset(s *state, cmd command, user database.User) error {
+	_, flags, err := parseFlags(cmd.args, "posts", "feeds", "users", "yes")
+	if err != nil {
+		return fmt.Errorf("%w.  Usage: gator %s [--posts|--feeds|--users] [--yes]", err, cmd.name)
+	}
+
+	scope, description, reset := "users", "ALL users, feeds, follows and posts", s.db.ResetUsers
+	switch {
+	case flags["posts"] != "":
+		scope, description, reset = "posts", "all posts", s.db.ResetPosts
+	case flags["feeds"] != "":
+		scope, description, reset = "feeds", "all feeds, along with their follows and posts", s.db.ResetFeeds
+	}
+
+	if flags["yes"] == "" {
+		fmt.Printf("This will permanently delete %s.\n", description)
+		answer, err := readLine(fmt.Sprintf("Type '%s' to confirm: ", scope))
+		if err != nil {
+			return fmt.Errorf("Couldn't read confirmation:\n%w", err)
+		}
+		if answer != scope {
+			fmt.Println("Reset cancelled")
+			return nil
+		}
+	}
+
+	if err := reset(context.Background()); err != nil {
+		return fmt.Errorf("Error reseting %s:\n%w", scope, err)
+	}
+	if scope == "users" {
+		// the session went with the users table
+		if err := s.cfg.SetUser("", ""); err != nil {
+			return fmt.Errorf("Couldn't clear user:\n%w", err)
+		}
+	}
+	fmt.Printf("Reset %s successfully\n", scope)
+	return nil
+}
+
+func handlerRole(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 || (cmd.args[1] != roleAdmin && cmd.args[1] != roleMember) {
+		return fmt.Errorf("Username and role required.  Usage: gator %s <name> <%s|%s>", cmd.name, roleAdmin, roleMember)
+	}
+	username := strings.ToLower(cmd.args[0])
+	role := cmd.args[1]
+
+	if role == roleMember {
+		target, err := s.db.GetUserByName(context.Background(), username)
+		if err != nil {
+			return fmt.Errorf("User '%s' not registered", username)
+		}
+		admins, err := s.db.CountAdmins(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error counting admins:\n%w", err)
+		}
+		if target.Role == roleAdmin && admins <= 1 {
+			return fmt.Errorf("Can't demote %s - they are the only admin", username)
+		}
+	}
+
+	updated, err := s.db.SetUserRole(context.Background(), database.SetUserRoleParams{
+		Name: username,
+		Role: role,
+	})
+	if err != nil {
+		return fmt.Errorf("Error setting role for '%s':\n%w", username, err)
+	}
+	fmt.Printf("%s is now %s\n", updated.Name, updated.Role)
 	return nil
 }
 
@@ -84,12 +325,158 @@ func handlerListUsers(s *state, cmd command) error {
 		return fmt.Errorf("Error retrieving users from db:\n%w", err)
 	}
 	for _, user := range allUsers {
-		if user == currentUser {
-			fmt.Printf("* %s (current)\n", user)
+		name := user.Name
+		if user.Role == roleAdmin {
+			name += " (admin)"
+		}
+		if user.Name == currentUser {
+			fmt.Printf("* %s (current)\n", name)
 		} else {
-			fmt.Printf("* %s\n", user)
+			fmt.Printf("* %s\n", name)
+		}
+	}
+	return nil
+}
+
+func handlerWhoami(s *state, cmd command, user database.User) error {
+	follows, err := s.db.CountFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("Error counting feed follows:\n%w", err)
+	}
+	fmt.Printf("Name: %s\n", user.Name)
+	fmt.Printf("ID: %s\n", user.ID)
+	fmt.Printf("Role: %s\n", user.Role)
+	fmt.Printf("Password protected: %t\n", user.HashedPassword.Valid)
+	fmt.Printf("Following: %d feeds\n", follows)
+	fmt.Printf("Created At: %s\n", user.CreatedAt)
+	return nil
+}
+
+func handlerRenameUser(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Old and new username required.  Usage: gator %s <old_name> <new_name>", cmd.name)
+	}
+	oldName := strings.ToLower(cmd.args[0])
+	newName := strings.ToLower(cmd.args[1])
+	if oldName != user.Name && user.Role != roleAdmin {
+		return errors.New("Only admins can rename other users")
+	}
+
+	renamed, err := s.db.RenameUser(context.Background(), database.RenameUserParams{
+		NewName: newName,
+		OldName: oldName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("User '%s' not registered", oldName)
+	}
+	if isUniqueViolation(err) {
+		return fmt.Errorf("Username '%s' is already taken", newName)
+	}
+	if err != nil {
+		return fmt.Errorf("Error renaming user:\n%w", err)
+	}
+
+	if renamed.ID == user.ID {
+		if err := s.cfg.SetUser(renamed.Name, s.cfg.SessionToken); err != nil {
+			return fmt.Errorf("Couldn't set user:\n%w", err)
+		}
+	}
+	fmt.Printf("User %s renamed to %s\n", oldName, renamed.Name)
+	return nil
+}
+
+func handlerDeleteUser(s *state, cmd command, user database.User) error {
+	args, flags, err := parseFlags(cmd.args, "yes")
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("Username required.  Usage: gator %s <name> [--yes]", cmd.name)
+	}
+	username := strings.ToLower(args[0])
+	target, err := s.db.GetUserByName(context.Background(), username)
+	if err != nil {
+		return fmt.Errorf("User '%s' not registered", username)
+	}
+	if target.Role == roleAdmin {
+		admins, err := s.db.CountAdmins(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error counting admins:\n%w", err)
+		}
+		if admins <= 1 {
+			return fmt.Errorf("Can't delete %s - they are the only admin", username)
+		}
+	}
+
+	// feeds the user added are handed over to their longest-standing other
+	// follower, so deleting a user doesn't pull feeds out from under others.
+	// Feeds nobody else follows go with the user.
+	feeds, err := s.db.GetFeedsByUser(context.Background(), target.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting user's feeds:\n%w", err)
+	}
+	follows, err := s.db.CountFeedFollowsForUser(context.Background(), target.ID)
+	if err != nil {
+		return fmt.Errorf("Error counting feed follows:\n%w", err)
+	}
+	transfers := []database.SetFeedOwnerParams{}
+	fmt.Printf("Deleting %s will:\n", target.Name)
+	fmt.Printf(" * remove their %d feed follows\n", follows)
+	for _, feed := range feeds {
+		newOwner, err := s.db.GetNextFeedOwner(context.Background(), database.GetNextFeedOwnerParams{
+			FeedID: feed.ID,
+			UserID: target.ID,
+		})
+		if err == nil {
+			transfers = append(transfers, database.SetFeedOwnerParams{
+				ID:     feed.ID,
+				UserID: newOwner.ID,
+			})
+			fmt.Printf(" * hand feed '%s' (%s) over to %s\n", feed.Name, feed.Url, newOwner.Name)
+			continue
 		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("Error finding new owner for %s:\n%w", feed.Name, err)
+		}
+		posts, err := s.db.CountPostsForFeed(context.Background(), feed.ID)
+		if err != nil {
+			return fmt.Errorf("Error counting posts:\n%w", err)
+		}
+		fmt.Printf(" * delete feed '%s' (%s) and its %d posts - nobody else follows it\n", feed.Name, feed.Url, posts)
+	}
+
+	if flags["yes"] == "" {
+		answer, err := readLine(fmt.Sprintf("Type '%s' to confirm: ", target.Name))
+		if err != nil {
+			return fmt.Errorf("Couldn't read confirmation:\n%w", err)
+		}
+		if answer != target.Name {
+			fmt.Println("Delete cancelled")
+			return nil
+		}
+	}
+
+	tx, err := s.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("Error starting transaction:\n%w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+	for _, transfer := range transfers {
+		if err := qtx.SetFeedOwner(context.Background(), transfer); err != nil {
+			return fmt.Errorf("Error transferring feed ownership:\n%w", err)
+		}
+	}
+	if err := qtx.DeleteUser(context.Background(), target.ID); err != nil {
+		return fmt.Errorf("Error deleting user:\n%w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Error committing transaction:\n%w", err)
+	}
+
+	if target.ID == user.ID {
+		if err := s.cfg.SetUser("", ""); err != nil {
+			return fmt.Errorf("Couldn't clear user:\n%w", err)
+		}
+	}
+	fmt.Printf("User %s deleted\n", target.Name)
 	return nil
 }
 
@@ -109,11 +496,40 @@ func handlerAggregate(s *state, cmd command) error {
 }
 
 func handlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.args) < 2 {
-		return fmt.Errorf("Feed name and URL required. Usage: gator %s <name_of_feed> <feed_url>", cmd.name)
+	args, flags, err := parseFlags(cmd.args, "import")
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("Feed URL required. Usage: gator %s [name_of_feed] <feed_url> [--import]", cmd.name)
+	}
+	name, rawURL := "", args[0]
+	if len(args) >= 2 {
+		name, rawURL = args[0], args[1]
+	}
+
+	candidate, err := resolveFeed(context.Background(), rawURL)
+	if err != nil {
+		return err
+	}
+	url, err := normalizeFeedURL(candidate.URL)
+	if err != nil {
+		return err
 	}
-	name := cmd.args[0]
-	url := cmd.args[1]
+	if existing, err := findFeed(context.Background(), s.db, url); err == nil {
+		return fmt.Errorf("Feed %s has already been added as '%s'.  Usage: gator follow %s", url, existing.Name, url)
+	}
+	rssFeed := candidate.Feed
+	if rssFeed == nil {
+		rssFeed, err = fetchFeed(context.Background(), url)
+		if err != nil {
+			return fmt.Errorf("%s doesn't look like a working feed:\n%w", url, err)
+		}
+	}
+	if name == "" {
+		name = strings.TrimSpace(rssFeed.Channel.Title)
+		if name == "" {
+			return fmt.Errorf("Feed has no title, so a name is required.  Usage: gator %s <name_of_feed> %s", cmd.name, url)
+		}
+	}
+
 	feed, err := s.db.CreateFeed(context.Background(), database.CreateFeedParams{
 		Name: name,
 		Url: url,
@@ -138,6 +554,17 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 	fmt.Printf("User: %s\n", feedFollow.UserName)
 	fmt.Printf("Created At: %s\n", feed.CreatedAt)
 	fmt.Printf("Updated At: %s\n", feed.UpdatedAt)
+
+	if flags["import"] != "" {
+		if _, err = s.db.MarkFeedFetched(context.Background(), feed.ID); err != nil {
+			return fmt.Errorf("Error marking feed as fetched:\n%w", err)
+		}
+		imported, err := savePosts(s, feed, rssFeed)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d posts\n", imported)
+	}
 	return nil
 }
 
@@ -153,23 +580,222 @@ func handlerListFeeds(s *state, cmd command) error {
 	}
 	
 	for _, feed := range feeds {
-		fmt.Printf("Feed name: %s\n", feed.FeedName)
-		fmt.Printf("URL: %s\n", feed.FeedUrl)
+		fmt.Printf("Feed name: %s\n", stripControl(feed.FeedName))
+		fmt.Printf("URL: %s\n", stripControl(feed.FeedUrl))
 		fmt.Printf("Added by: %s\n", feed.UserName)
+		if feed.DeadAt.Valid {
+			fmt.Printf("Gone since: %s\n", feed.DeadAt.Time.Format(time.DateTime))
+		}
 		fmt.Println()
 	}
 	return nil
 }
 
+func handlerRenameFeed(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Feed URL and new name required.  Usage: gator %s <feed_url> <new_name>", cmd.name)
+	}
+	feed, err := getManagedFeed(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	renamed, err := s.db.RenameFeed(context.Background(), database.RenameFeedParams{
+		ID:   feed.ID,
+		Name: cmd.args[1],
+	})
+	if err != nil {
+		return fmt.Errorf("Error renaming feed:\n%w", err)
+	}
+	fmt.Printf("Feed '%s' renamed to '%s'\n", feed.Name, renamed.Name)
+	return nil
+}
+
+func handlerSetFeedURL(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Current and new feed URL required.  Usage: gator %s <feed_url> <new_feed_url>", cmd.name)
+	}
+	feed, err := getManagedFeed(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	newURL, err := normalizeFeedURL(cmd.args[1])
+	if err != nil {
+		return err
+	}
+	if existing, err := findFeed(context.Background(), s.db, newURL); err == nil && existing.ID != feed.ID {
+		return fmt.Errorf("%s is already used by feed '%s'", newURL, existing.Name)
+	}
+	updated, err := s.db.SetFeedURL(context.Background(), database.SetFeedURLParams{
+		ID:  feed.ID,
+		Url: newURL,
+	})
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%s is already used by another feed", newURL)
+	}
+	if err != nil {
+		return fmt.Errorf("Error updating feed URL:\n%w", err)
+	}
+	fmt.Printf("Feed '%s' now fetched from %s\n", updated.Name, updated.Url)
+	return nil
+}
+
+func handlerDeleteFeed(s *state, cmd command, user database.User) error {
+	args, flags, err := parseFlags(cmd.args, "yes")
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("Feed URL required.  Usage: gator %s <feed_url> [--yes]", cmd.name)
+	}
+	feed, err := getManagedFeed(s, user, args[0])
+	if err != nil {
+		return err
+	}
+	followers, err := s.db.CountFollowersForFeed(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("Error counting followers:\n%w", err)
+	}
+	posts, err := s.db.CountPostsForFeed(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("Error counting posts:\n%w", err)
+	}
+
+	fmt.Printf("Deleting feed '%s' (%s) will remove %d followers and %d posts.\n", feed.Name, feed.Url, followers, posts)
+	if flags["yes"] == "" {
+		answer, err := readLine("Type 'delete' to confirm: ")
+		if err != nil {
+			return fmt.Errorf("Couldn't read confirmation:\n%w", err)
+		}
+		if answer != "delete" {
+			fmt.Println("Delete cancelled")
+			return nil
+		}
+	}
+	if err := s.db.DeleteFeed(context.Background(), feed.ID); err != nil {
+		return fmt.Errorf("Error deleting feed:\n%w", err)
+	}
+	fmt.Printf("Feed '%s' deleted\n", feed.Name)
+	return nil
+}
+
+func handlerFeedFullText(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 || (cmd.args[1] != "on" && cmd.args[1] != "off") {
+		return fmt.Errorf("Feed URL and on/off required.  Usage: gator %s <feed_url> <on|off>", cmd.name)
+	}
+	feed, err := getManagedFeed(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	if err := s.db.SetFeedFetchFullText(context.Background(), database.SetFeedFetchFullTextParams{
+		ID:            feed.ID,
+		FetchFullText: cmd.args[1] == "on",
+	}); err != nil {
+		return fmt.Errorf("Error updating feed:\n%w", err)
+	}
+	if cmd.args[1] == "on" {
+		fmt.Printf("Full articles will be fetched for new posts from '%s'\n", feed.Name)
+	} else {
+		fmt.Printf("Full articles won't be fetched for '%s' anymore\n", feed.Name)
+	}
+	return nil
+}
+
+func handlerFeedHistory(s *state, cmd command) error {
+	args, flags, err := parseFlags(cmd.args)
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("Feed URL required.  Usage: gator %s <feed_url> [--limit <n>]", cmd.name)
+	}
+	limit := 10
+	if flags["limit"] != "" {
+		limit, err = strconv.Atoi(flags["limit"])
+		if err != nil || limit < 1 {
+			return fmt.Errorf("Invalid limit: %s", flags["limit"])
+		}
+	}
+	feed, err := findFeed(context.Background(), s.db, args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("No feed found with URL %s", args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("Error getting feed from db:\n%w", err)
+	}
+	fetches, err := s.db.GetFeedFetches(context.Background(), database.GetFeedFetchesParams{
+		FeedID: feed.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("Error getting fetch history:\n%w", err)
+	}
+
+	fmt.Printf("Feed '%s' (%s)\n", stripControl(feed.Name), stripControl(feed.Url))
+	if feed.DeadAt.Valid {
+		fmt.Printf("Gone since %s - not fetched anymore\n", feed.DeadAt.Time.Format(time.DateTime))
+	}
+	if feed.RetryAfter.Valid {
+		fmt.Printf("Next fetch not before %s\n", feed.RetryAfter.Time.Format(time.DateTime))
+	}
+	if len(fetches) == 0 {
+		fmt.Println("Not fetched yet.")
+		return nil
+	}
+	for _, fetch := range fetches {
+		status := "no response"
+		if fetch.StatusCode.Valid {
+			status = fmt.Sprintf("%d %s", fetch.StatusCode.Int32, http.StatusText(int(fetch.StatusCode.Int32)))
+		}
+		fmt.Printf("%s  %s", fetch.CreatedAt.Format(time.DateTime), status)
+		if fetch.Url != feed.Url {
+			fmt.Printf("  (from %s)", stripControl(fetch.Url))
+		}
+		fmt.Println()
+		if fetch.Error.Valid {
+			fmt.Printf("  %s\n", stripControl(strings.ReplaceAll(fetch.Error.String, "\n", " ")))
+		}
+	}
+	return nil
+}
+
+// getManagedFeed looks up a feed that user is allowed to edit - one they
+// added themselves, or any feed if they're an admin.
+func getManagedFeed(s *state, user database.User, feedURL string) (database.Feed, error) {
+	feed, err := findFeed(context.Background(), s.db, feedURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, fmt.Errorf("No feed found with URL %s", feedURL)
+	}
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("Error getting feed from db:\n%w", err)
+	}
+	if feed.UserID != user.ID && user.Role != roleAdmin {
+		return database.Feed{}, fmt.Errorf("Only the user who added '%s' or an admin can change it", feed.Name)
+	}
+	return feed, nil
+}
+
 func handlerFollow (s *state, cmd command, user database.User) error {
-	if len(cmd.args) < 1 {
-		return fmt.Errorf("Feed URL required.  Usage: gator %s <feed_url>", cmd.name)
+	args, flags, err := parseFlags(cmd.args)
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("Feed URL required.  Usage: gator %s <feed_url> [--tag <tag>]", cmd.name)
+	}
+	url := args[0]
+	tag := ""
+	if _, ok := flags["tag"]; ok {
+		if tag, err = normalizeTag(flags["tag"]); err != nil {
+			return err
+		}
 	}
-	url := cmd.args[0]
 
-	feed, err := s.db.GetFeedByURL(context.Background(), url)
+	feed, err := findFeed(context.Background(), s.db, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		// maybe it's the website rather than the feed itself
+		candidate, resolveErr := resolveFeed(context.Background(), url)
+		if resolveErr != nil {
+			return fmt.Errorf("No feed with URL %s has been added:\n%w", url, resolveErr)
+		}
+		url = candidate.URL
+		feed, err = findFeed(context.Background(), s.db, url)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("No feed with URL %s has been added yet.  Usage: gator addfeed %s", url, url)
+	}
 	if err != nil {
-		return fmt.Errorf("Error getting user info from db:\n%w", err)
+		return fmt.Errorf("Error getting feed from db:\n%w", err)
 	}
 
 	feedFollow, err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
@@ -179,10 +805,23 @@ func handlerFollow (s *state, cmd command, user database.User) error {
 	if err != nil {
 		return fmt.Errorf("Error creating feed follow:\n%w", err)
 	}
+	if tag != "" {
+		feedFollow.Tags = addTag(feedFollow.Tags, tag)
+		if err = s.db.SetFeedFollowTags(context.Background(), database.SetFeedFollowTagsParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+			Tags:   feedFollow.Tags,
+		}); err != nil {
+			return fmt.Errorf("Error saving tags:\n%w", err)
+		}
+	}
 
 	fmt.Println("Feed followed:")
 	fmt.Printf("ID: %s\n", feedFollow.ID)
 	fmt.Printf("Name: %s\n", feedFollow.FeedName)
+	if len(feedFollow.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(feedFollow.Tags, ", "))
+	}
 	fmt.Printf("Username: %s\n", feedFollow.UserName)
 	fmt.Printf("Created At: %s\n", feedFollow.CreatedAt)
 	fmt.Printf("Updated At: %s\n", feedFollow.UpdatedAt)
@@ -200,9 +839,37 @@ func handlerFollowing (s *state, cmd command, user database.User) error {
 		return nil
 	}
 
-	fmt.Println("You follow:")
-	for _, follows := range follows {
-		fmt.Printf(" * %s\n", follows.FeedName)
+	// feeds are listed under each of their tags, untagged ones last
+	byTag := map[string][]string{}
+	untagged := []string{}
+	for _, follow := range follows {
+		name := stripControl(follow.FeedName)
+		if len(follow.Tags) == 0 {
+			untagged = append(untagged, name)
+		}
+		for _, tag := range follow.Tags {
+			byTag[tag] = append(byTag[tag], name)
+		}
+	}
+	if len(byTag) == 0 {
+		fmt.Println("You follow:")
+		for _, name := range untagged {
+			fmt.Printf(" * %s\n", name)
+		}
+		return nil
+	}
+	tags := slices.Sorted(maps.Keys(byTag))
+	for _, tag := range tags {
+		fmt.Printf("%s:\n", tag)
+		for _, name := range byTag[tag] {
+			fmt.Printf(" * %s\n", name)
+		}
+	}
+	if len(untagged) > 0 {
+		fmt.Println("Untagged:")
+		for _, name := range untagged {
+			fmt.Printf(" * %s\n", name)
+		}
 	}
 	return nil
 }
@@ -211,7 +878,7 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	if len(cmd.args) < 1 {
 		return fmt.Errorf("Feed URL required.  Usage: gator %s <feed_url>", cmd.name)
 	}
-	feed, err := s.db.GetFeedByURL(context.Background(), cmd.args[0])
+	feed, err := findFeed(context.Background(), s.db, cmd.args[0])
 	if err != nil {
 		return fmt.Errorf("Error getting feed with the given URL:\n%w", err)
 	}
@@ -225,10 +892,42 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	return nil
 }
 
+func handlerTitle(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf("Feed URL required.  Usage: gator %s <feed_url> [name]", cmd.name)
+	}
+	feed, _, err := getFollow(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	// only this user sees the title, so there's no owner check like feed rename
+	title := nullString(strings.Join(cmd.args[1:], " "))
+	if title.String == feed.Name {
+		title = sql.NullString{}
+	}
+	if err = s.db.SetFeedFollowTitle(context.Background(), database.SetFeedFollowTitleParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+		Title:  title,
+	}); err != nil {
+		return fmt.Errorf("Error saving title:\n%w", err)
+	}
+	if !title.Valid {
+		fmt.Printf("You'll see '%s' by its own name again\n", feed.Name)
+		return nil
+	}
+	fmt.Printf("You'll see '%s' as '%s'\n", feed.Name, title.String)
+	return nil
+}
+
 func handlerBrowse(s *state, cmd command, user database.User) error {
+	args, flags, err := parseFlags(cmd.args, "unfiltered")
+	if err != nil {
+		return fmt.Errorf("%w.  Usage: gator %s [post_limit] [--author <name>] [--category <name>] [--tag <tag>] [--unfiltered]", err, cmd.name)
+	}
 	postLimit := 2
-	if len(cmd.args) >= 1 {
-		limit, err := strconv.Atoi(cmd.args[0])
+	if len(args) >= 1 {
+		limit, err := strconv.Atoi(args[0])
 		if err != nil || limit < 1 {
 			fmt.Printf("Invalid post limit - defaulting to %d.  Usage:  gator %s <post_limit>\n", postLimit, cmd.name)
 		} else {
@@ -237,26 +936,87 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 	}
 
 	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit: int32(postLimit),
+		UserID:     user.ID,
+		Author:     nullString(flags["author"]),
+		Category:   nullString(flags["category"]),
+		Tag:        nullString(flags["tag"]),
+		Unfiltered: flags["unfiltered"] != "",
+		Limit:      int32(postLimit),
 	})
 	if err != nil {
 		return fmt.Errorf("Error getting posts from db:\n%w", err)
 	}
 
-	fmt.Printf("Displaying most recent %d posts:\n", postLimit)
+	// remembered so the posts can be opened by their number
+	postIDs := make([]int64, 0, len(posts))
 	for _, post := range posts {
-		fmt.Printf("%s\n", post.Title)
-		fmt.Printf("Link: %s\n", post.Url)
+		postIDs = append(postIDs, post.Seq)
+	}
+	if err := s.cfg.SetLastBrowse(postIDs); err != nil {
+		return fmt.Errorf("Error saving post list to config:\n%w", err)
+	}
+
+	opts := terminalOptions()
+	fmt.Printf("Displaying most recent %d posts:\n", postLimit)
+	for i, post := range posts {
+		fmt.Printf("[%d] %s\n", i+1, stripControl(post.Title))
+		fmt.Printf("ID: %d\n", post.Seq)
+		fmt.Printf("Link: %s\n", stripControl(post.Url))
 		if post.PublishedAt.Valid {
 			fmt.Printf("Published: %v\n", post.PublishedAt.Time)
 		} else {
 			fmt.Println("Published: unknown")
 		}
-		fmt.Printf("By: %s\n", post.FeedName)
+		fmt.Printf("By: %s\n", stripControl(post.FeedName))
+		if post.Author.Valid {
+			fmt.Printf("Author: %s\n", stripControl(post.Author.String))
+		}
+		if len(post.Categories) > 0 {
+			fmt.Printf("Categories: %s\n", stripControl(strings.Join(post.Categories, ", ")))
+		}
+		if len(post.Tags) > 0 {
+			fmt.Printf("Tags: %s\n", stripControl(strings.Join(post.Tags, ", ")))
+		}
+		if post.CommentsUrl.Valid {
+			fmt.Printf("Comments: %s\n", stripControl(post.CommentsUrl.String))
+		}
+		if post.ImageUrl.Valid {
+			fmt.Printf("Image: %s\n", stripControl(post.ImageUrl.String))
+		}
+		if post.Episode.Valid {
+			fmt.Printf("Episode: %d\n", post.Episode.Int32)
+		}
+		if post.DurationSeconds.Valid {
+			fmt.Printf("Duration: %s\n", formatDuration(int(post.DurationSeconds.Int32)))
+		}
+		enclosures, err := s.db.GetEnclosuresForPost(context.Background(), post.ID)
+		if err != nil {
+			return fmt.Errorf("Error getting enclosures from db:\n%w", err)
+		}
+		for _, enclosure := range enclosures {
+			details := []string{}
+			if enclosure.MimeType.Valid {
+				details = append(details, stripControl(enclosure.MimeType.String))
+			}
+			if enclosure.Length.Valid {
+				details = append(details, formatBytes(enclosure.Length.Int64))
+			}
+			fmt.Printf("Media: %s", stripControl(enclosure.Url))
+			if len(details) > 0 {
+				fmt.Printf(" (%s)", strings.Join(details, ", "))
+			}
+			fmt.Println()
+		}
+		if len(enclosures) > 0 {
+			fmt.Printf("Download with: gator download %d\n", post.Seq)
+		}
 		fmt.Println("----------------------------------------")
-		if post.Description.Valid {
-			fmt.Printf("%s\n", post.Description.String)
+		if post.FullContent.Valid {
+			fmt.Printf("%s\n", renderHTML(post.FullContent.String, opts))
+		} else if post.Content.Valid {
+			fmt.Printf("%s\n", renderHTML(post.Content.String, opts))
+		} else if post.Description.Valid {
+			fmt.Printf("%s\n", renderHTML(post.Description.String, opts))
 		} else {
 			fmt.Println("No description available")
 		}
@@ -264,4 +1024,151 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 		fmt.Println()
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func handlerRead(s *state, cmd command, user database.User) error {
+	args, flags, err := parseFlags(cmd.args, "full")
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("Post required.  Usage: gator %s <post_id|post_url> [--full]", cmd.name)
+	}
+	post, err := resolvePost(s, args[0])
+	if err != nil {
+		return err
+	}
+
+	body := post.FullContent.String
+	if !post.FullContent.Valid && flags["full"] != "" {
+		fmt.Printf("Fetching the full article from %s\n", stripControl(post.Url))
+		body, err = saveFullText(s, post)
+		if err != nil {
+			fmt.Printf("Couldn't get the full article, showing the feed's version: %v\n", err)
+		}
+	}
+	if body == "" {
+		body = post.Content.String
+	}
+	if body == "" {
+		body = post.Description.String
+	}
+
+	fmt.Printf("%s\n", stripControl(post.Title))
+	fmt.Printf("Link: %s\n", stripControl(post.Url))
+	if post.PublishedAt.Valid {
+		fmt.Printf("Published: %v\n", post.PublishedAt.Time)
+	}
+	if post.Author.Valid {
+		fmt.Printf("Author: %s\n", stripControl(post.Author.String))
+	}
+	fmt.Println("----------------------------------------")
+	if body == "" {
+		fmt.Println("No content available - try --full")
+		return nil
+	}
+	fmt.Printf("%s\n", renderHTML(body, terminalOptions()))
+	return nil
+}
+
+func handlerOpen(s *state, cmd command, user database.User) error {
+	args, flags, err := parseFlags(cmd.args, "mark-read")
+	if err != nil || (len(args) < 1 && flags["index"] == "") {
+		return fmt.Errorf("Post required.  Usage: gator %s <post_id|post_url> | --index <n> [--mark-read]", cmd.name)
+	}
+	ref := ""
+	if flags["index"] != "" {
+		index, err := strconv.Atoi(flags["index"])
+		if err != nil || index < 1 {
+			return fmt.Errorf("Invalid index: %s", flags["index"])
+		}
+		if index > len(s.cfg.LastBrowse) {
+			return fmt.Errorf("There's no post [%d] - the last browse showed %d posts", index, len(s.cfg.LastBrowse))
+		}
+		ref = strconv.FormatInt(s.cfg.LastBrowse[index-1], 10)
+	} else {
+		ref = args[0]
+	}
+	post, err := resolvePost(s, ref)
+	if err != nil {
+		return err
+	}
+
+	if err := openBrowser(post.Url); err != nil {
+		return err
+	}
+	fmt.Printf("Opened '%s' (%s)\n", stripControl(post.Title), stripControl(post.Url))
+	if flags["mark-read"] != "" {
+		if err := s.db.SetPostRead(context.Background(), database.SetPostReadParams{
+			UserID: user.ID,
+			PostID: post.ID,
+			IsRead: true,
+		}); err != nil {
+			return fmt.Errorf("Error marking post read:\n%w", err)
+		}
+		fmt.Println("Marked as read")
+	}
+	return nil
+}
+
+func handlerAPIKey(s *state, cmd command, user database.User) error {
+	key, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("Error generating API key:\n%w", err)
+	}
+	if err := s.db.SetUserAPIKeyHash(context.Background(), database.SetUserAPIKeyHashParams{
+		ID: user.ID,
+		ApiKeyHash: sql.NullString{
+			String: hashToken(key),
+			Valid:  true,
+		},
+	}); err != nil {
+		return fmt.Errorf("Error saving API key:\n%w", err)
+	}
+	fmt.Printf("API key for %s (replaces any previous key, and is only shown once):\n", user.Name)
+	fmt.Println(key)
+	fmt.Println("Use your username and this key as the password in Google Reader API clients.")
+	return nil
+}
+
+func handlerPublish(s *state, cmd command) error {
+	_, flags, err := parseFlags(cmd.args)
+	if err != nil {
+		return fmt.Errorf("%w.  Usage: gator %s [--user <name>] [--output <file>] [--limit <post_limit>]", err, cmd.name)
+	}
+	username := s.cfg.CurrentUsername
+	if flags["user"] != "" {
+		username = strings.ToLower(flags["user"])
+	}
+	postLimit := 50
+	if flags["limit"] != "" {
+		limit, err := strconv.Atoi(flags["limit"])
+		if err != nil || limit < 1 {
+			return fmt.Errorf("Invalid post limit: %s", flags["limit"])
+		}
+		postLimit = limit
+	}
+
+	user, err := s.db.GetUserByName(context.Background(), username)
+	if err != nil {
+		return fmt.Errorf("User '%s' not registered", username)
+	}
+	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
+		UserID: user.ID,
+		Limit:  int32(postLimit),
+	})
+	if err != nil {
+		return fmt.Errorf("Error getting posts from db:\n%w", err)
+	}
+	data, err := renderUserAtom(user, posts, "")
+	if err != nil {
+		return err
+	}
+
+	if flags["output"] == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(flags["output"], data, 0644); err != nil {
+		return fmt.Errorf("Error writing atom feed:\n%w", err)
+	}
+	fmt.Printf("Wrote %d posts for %s to %s\n", len(posts), user.Name, flags["output"])
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dateLayouts are tried in order once parseTime has cleaned a date up: the
+// weekday and ordinal suffixes are gone, month names are abbreviated,
+// whitespace is collapsed and named zones have been turned into numeric
+// offsets.  The comments show dates seen in real feeds that each
+// layout exists for.
+var dateLayouts = []string{
+	// RSS 2.0 / RFC 822 and the ways feeds get it slightly wrong
+	"2 Jan 2006 15:04:05 -0700",  // Mon, 02 Jan 2006 15:04:05 -0700, Tue, 5 Mar 2024 09:30:00 GMT
+	"2 Jan 2006 15:04:05 -07:00", // Wed, 15 May 2024 10:00:00 +02:00
+	"2 Jan 2006 15:04 -0700",     // Thu, 7 Nov 2019 08:15 EST, Sunday, 12 September 2021 18:00 BST
+	"2 Jan 06 15:04:05 -0700",    // Fri, 03 Jul 20 17:45:12 +0000
+	"2 Jan 06 15:04 -0700",       // 3 Jul 20 17:45 PDT
+	"2 Jan 2006 15:04:05",        // Sat, 01 Jun 2024 12:00:00
+	"2 Jan 2006 15:04",           // 01 Jun 2024 12:00
+	"2 Jan 2006",                 // 01 Jun 2024, 1st June 2024
+
+	// month first, as written by hand or by US CMSes
+	"Jan 2, 2006 15:04:05 -0700", // June 4, 2024 10:22:01 -0500
+	"Jan 2, 2006 15:04 -0700",    // Jun 4, 2024 10:22 CDT
+	"Jan 2, 2006 3:04 PM -0700",  // Jun 4, 2024 10:22 AM EDT
+	"Jan 2, 2006 3:04 PM",        // June 4, 2024 3:05 PM
+	"Jan 2, 2006",                // June 4, 2024, Sept 4th, 2024
+	"Jan 2 2006 15:04:05 -0700",  // Jun 4 2024 10:22:01 +0000
+	"Jan 2 15:04:05 -0700 2006",  // Tue Jun 4 10:22:01 +0000 2024 (Twitter, UnixDate)
+	"Jan 2 15:04:05 2006",        // Tue Jun  4 10:22:01 2024 (ANSI C)
+
+	// ISO 8601 / RFC 3339 / Atom, with the variations feeds actually use
+	time.RFC3339Nano,                // 2024-06-04T10:22:01.123Z, 2024-06-04T10:22:01+02:00
+	"2006-01-02T15:04:05-0700",      // 2024-06-04T10:22:01+0200
+	"2006-01-02T15:04Z07:00",        // 2024-06-04T10:22Z
+	"2006-01-02T15:04:05.999999999", // 2024-06-04T10:22:01.5 (no zone)
+	"2006-01-02T15:04",              // 2024-06-04T10:22
+	"2006-01-02 15:04:05Z07:00",     // 2024-06-04 10:22:01Z
+	"2006-01-02 15:04:05 -0700",     // 2024-06-04 10:22:01 -0500
+	"2006-01-02 15:04:05 -07:00",    // 2024-06-04 10:22:01 -05:00
+	"2006-01-02 15:04:05.999999999", // 2024-06-04 10:22:01.000000 (SQL dumps)
+	"2006-01-02 15:04",              // 2024-06-04 10:22
+	"2006-01-02",                    // 2024-06-04
+	"2006/01/02 15:04:05",           // 2024/06/04 10:22:01
+	"2006/01/02",                    // 2024/06/04
+}
+
+// zoneOffsets maps the zone names found in feeds to their offsets.  Go's own
+// parser accepts any abbreviation but treats the ones it doesn't know as UTC,
+// so they're swapped for numbers first.
+var zoneOffsets = map[string]string{
+	"UT": "+0000", "UTC": "+0000", "GMT": "+0000", "Z": "+0000", "WET": "+0000",
+	"EST": "-0500", "EDT": "-0400",
+	"CST": "-0600", "CDT": "-0500",
+	"MST": "-0700", "MDT": "-0600",
+	"PST": "-0800", "PDT": "-0700",
+	"AKST": "-0900", "AKDT": "-0800",
+	"HST": "-1000",
+	"AST": "-0400", "ADT": "-0300",
+	"NST": "-0330", "NDT": "-0230",
+	"BST": "+0100", "IST": "+0530", "WEST": "+0100",
+	"CET": "+0100", "CEST": "+0200", "MET": "+0100", "MEST": "+0200",
+	"EET": "+0200", "EEST": "+0300",
+	"MSK": "+0300",
+	"JST": "+0900", "KST": "+0900",
+	"HKT": "+0800", "SGT": "+0800", "AWST": "+0800",
+	"ACST": "+0930", "ACDT": "+1030",
+	"AEST": "+1000", "AEDT": "+1100",
+	"NZST": "+1200", "NZDT": "+1300",
+}
+
+var (
+	weekdayPrefix = regexp.MustCompile(`(?i)^(mon|tue|wed|thu|fri|sat|sun)[a-z]*\.?,?\s+`)
+	zoneComment   = regexp.MustCompile(`\s*\([^)]*\)$`)
+	zoneName      = regexp.MustCompile(`\s([A-Za-z]{1,5})$`)
+	ordinalSuffix = regexp.MustCompile(`(\d)(st|nd|rd|th)\b`)
+	monthName     = regexp.MustCompile(`(?i)\b(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?`)
+)
+
+// parseTime parses the publication dates found in feeds, which are supposed
+// to be RFC 822 (RSS) or RFC 3339 (Atom) but often aren't.  Dates without a
+// zone are taken to be UTC.  The result is always in UTC.
+func parseTime(timeStr string) (time.Time, error) {
+	cleaned := strings.Join(strings.Fields(timeStr), " ")
+	if cleaned == "" {
+		return time.Time{}, fmt.Errorf("no time given")
+	}
+	cleaned = zoneComment.ReplaceAllString(cleaned, "")
+	cleaned = weekdayPrefix.ReplaceAllString(cleaned, "")
+	cleaned = ordinalSuffix.ReplaceAllString(cleaned, "$1")
+	cleaned = monthName.ReplaceAllString(cleaned, "$1")
+	if match := zoneName.FindStringSubmatch(cleaned); match != nil {
+		if offset, ok := zoneOffsets[strings.ToUpper(match[1])]; ok {
+			cleaned = strings.TrimSuffix(cleaned, match[1]) + offset
+		}
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, cleaned); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse time: %s", timeStr)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseTime checks the real-world dates noted next to dateLayouts.
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		// RSS 2.0 / RFC 822
+		{"Mon, 02 Jan 2006 15:04:05 -0700", time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC)},
+		{"Tue, 5 Mar 2024 09:30:00 GMT", time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)},
+		{"Wed, 15 May 2024 10:00:00 +02:00", time.Date(2024, 5, 15, 8, 0, 0, 0, time.UTC)},
+		{"Thu, 7 Nov 2019 08:15 EST", time.Date(2019, 11, 7, 13, 15, 0, 0, time.UTC)},
+		{"Sunday, 12 September 2021 18:00 BST", time.Date(2021, 9, 12, 17, 0, 0, 0, time.UTC)},
+		{"Fri, 03 Jul 20 17:45:12 +0000", time.Date(2020, 7, 3, 17, 45, 12, 0, time.UTC)},
+		{"3 Jul 20 17:45 PDT", time.Date(2020, 7, 4, 0, 45, 0, 0, time.UTC)},
+		{"Thu, 01 Jan 98 00:00:00 GMT", time.Date(1998, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"Sat, 01 Jun 2024 12:00:00", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{"01 Jun 2024 12:00", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{"01 Jun 2024", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"1st June 2024", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"Tue, 04 Jun 2024 10:22:01 +0000 (UTC)", time.Date(2024, 6, 4, 10, 22, 1, 0, time.UTC)},
+
+		// month first
+		{"June 4, 2024 10:22:01 -0500", time.Date(2024, 6, 4, 15, 22, 1, 0, time.UTC)},
+		{"Jun 4, 2024 10:22 CDT", time.Date(2024, 6, 4, 15, 22, 0, 0, time.UTC)},
+		{"Jun 4, 2024 10:22 AM EDT", time.Date(2024, 6, 4, 14, 22, 0, 0, time.UTC)},
+		{"June 4, 2024 3:05 PM", time.Date(2024, 6, 4, 15, 5, 0, 0, time.UTC)},
+		{"June 4, 2024", time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)},
+		{"Sept 4th, 2024", time.Date(2024, 9, 4, 0, 0, 0, 0, time.UTC)},
+		{"Jun 4 2024 10:22:01 +0000", time.Date(2024, 6, 4, 10, 22, 1, 0, time.UTC)},
+		{"Tue Jun 4 10:22:01 +0000 2024", time.Date(2024, 6, 4, 10, 22, 1, 0, time.UTC)},
+		{"Tue Jun  4 10:22:01 2024", time.Date(2024, 6, 4, 10, 22, 1, 0, time.UTC)},
+
+		// ISO 8601 / RFC 3339
+		{"2024-06-04T10:22:01.123Z", time.Date(2024, 6, 4, 10, 22, 1, 123000000, time.UTC)},
+		{"2024-06-04T10:22:01+02:00", time.Date(2024, 6, 4, 8, 22, 1, 0, time.UTC)},
+		{"2024-06-04T10:22:01+0200", time.Date(2024, 6, 4, 8, 22, 1, 0, time.UTC)},
+		{"2024-06-04T10:22Z", time.Date(2024, 6, 4, 10, 22, 0, 0, time.UTC)},
+		{"2024-06-04T10:22:01.5", time.Date(2024, 6, 4, 10, 22, 1, 500000000, time.UTC)},
+		{"2024-06-04T10:22", time.Date(2024, 6, 4, 10, 22, 0, 0, time.UTC)},
+		{"2024-06-04 10:22:01Z", time.Date(2024, 6, 4, 10, 22, 1, 0, time.UTC)},
+		{"2024-06-04 10:22:01 -0500", time.Date(2024, 6, 4, 15, 22, 1, 0, time.UTC)},
+		{"2024-06-04 10:22:01 -05:00", time.Date(2024, 6, 4, 15, 22, 1, 0, time.UTC)},
+		{"2024-06-04 10:22:01.000000", time.Date(2024, 6, 4, 10, 22, 1, 0, time.UTC)},
+		{"2024-06-04 10:22", time.Date(2024, 6, 4, 10, 22, 0, 0, time.UTC)},
+		{"2024-06-04", time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)},
+		{"2024/06/04 10:22:01", time.Date(2024, 6, 4, 10, 22, 1, 0, time.UTC)},
+		{"2024/06/04", time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.input)
+		if err != nil {
+			t.Errorf("parseTime(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("parseTime(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeInvalid(t *testing.T) {
+	for _, input := range []string{"", "   ", "yesterday", "32 Foo 2024"} {
+		if got, err := parseTime(input); err == nil {
+			t.Errorf("parseTime(%q) = %v, want an error", input, got)
+		}
+	}
+}
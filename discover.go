@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// feedTypes are the <link type> values that advertise a feed.
+var feedTypes = []string{
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/feed+json",
+}
+
+// commonFeedPaths are tried, relative to the site root, when a page doesn't
+// advertise any feeds itself.
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss",
+	"/feed.xml",
+	"/rss.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+type feedCandidate struct {
+	URL   string
+	Title string
+	// Feed is set when discovery already had to fetch and parse the feed
+	Feed *RSSFeed
+}
+
+// resolveFeed checks that pageURL is a feed, and if it's a website instead,
+// discovers the feeds it links to.  When there's more than one the user is
+// asked to choose.  Like feed URLs, pageURL can leave out the scheme.
+func resolveFeed(ctx context.Context, pageURL string) (feedCandidate, error) {
+	pageURL, err := normalizeFeedURL(pageURL)
+	if err != nil {
+		return feedCandidate{}, err
+	}
+	candidates, err := discoverFeeds(ctx, pageURL)
+	if err != nil {
+		return feedCandidate{}, err
+	}
+	switch len(candidates) {
+	case 0:
+		return feedCandidate{}, fmt.Errorf("No feeds found at %s", pageURL)
+	case 1:
+		if candidates[0].URL != pageURL {
+			fmt.Printf("Found feed: %s\n", stripControl(candidates[0].URL))
+		}
+		return candidates[0], nil
+	}
+
+	fmt.Printf("%s links to several feeds:\n", pageURL)
+	for i, candidate := range candidates {
+		fmt.Printf("  %d) %s (%s)\n", i+1, stripControl(candidate.Title), stripControl(candidate.URL))
+	}
+	answer, err := readLine(fmt.Sprintf("Choose a feed [1-%d]: ", len(candidates)))
+	if err != nil {
+		return feedCandidate{}, fmt.Errorf("Couldn't read choice:\n%w", err)
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return feedCandidate{}, fmt.Errorf("Invalid choice: %s", answer)
+	}
+	return candidates[choice-1], nil
+}
+
+// discoverFeeds returns pageURL itself if it's a feed, otherwise the feeds
+// advertised by the page's <link rel="alternate"> tags, falling back to
+// probing commonFeedPaths.
+func discoverFeeds(ctx context.Context, pageURL string) ([]feedCandidate, error) {
+	res, err := fetchURL(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if feed, err := parseFeed(bytes.NewReader(res.Body), res.ContentType); err == nil {
+		feedURL := pageURL
+		if res.Moved {
+			feedURL = res.URL
+		}
+		return []feedCandidate{{URL: feedURL, Title: feed.Channel.Title, Feed: feed}}, nil
+	}
+
+	base, err := url.Parse(res.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid URL %s:\n%w", res.URL, err)
+	}
+	candidates, err := findFeedLinks(res.Body, base)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	for _, path := range commonFeedPaths {
+		candidateURL := base.ResolveReference(&url.URL{Path: path}).String()
+		res, err := fetchURL(ctx, candidateURL)
+		if err != nil {
+			continue
+		}
+		if res.Moved {
+			candidateURL = res.URL
+		}
+		if feed, err := parseFeed(bytes.NewReader(res.Body), res.ContentType); err == nil {
+			candidates = append(candidates, feedCandidate{URL: candidateURL, Title: feed.Channel.Title, Feed: feed})
+			// /feed and /rss usually serve the same thing as the .xml
+			// variants, so stop at the first hit
+			break
+		}
+	}
+	return candidates, nil
+}
+
+// findFeedLinks collects the feed <link> tags in an HTML page, resolving
+// their hrefs against base.
+func findFeedLinks(body []byte, base *url.URL) ([]feedCandidate, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing html:\n%w", err)
+	}
+
+	candidates := []feedCandidate{}
+	seen := map[string]bool{}
+	for node := range doc.Descendants() {
+		if node.Type != html.ElementNode || node.Data != "link" {
+			continue
+		}
+		attrs := map[string]string{}
+		for _, attr := range node.Attr {
+			attrs[strings.ToLower(attr.Key)] = attr.Val
+		}
+		if !hasToken(attrs["rel"], "alternate") || !isFeedType(attrs["type"]) || attrs["href"] == "" {
+			continue
+		}
+		href, err := base.Parse(strings.TrimSpace(attrs["href"]))
+		if err != nil || seen[href.String()] {
+			continue
+		}
+		seen[href.String()] = true
+		title := attrs["title"]
+		if title == "" {
+			title = attrs["type"]
+		}
+		candidates = append(candidates, feedCandidate{URL: href.String(), Title: title})
+	}
+	return candidates, nil
+}
+
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(strings.ToLower(list)) {
+		if field == token {
+			return true
+		}
+	}
+	return false
+}
+
+func isFeedType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(strings.ToLower(mimeType), ";")
+	for _, feedType := range feedTypes {
+		if strings.TrimSpace(mimeType) == feedType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/thomas-reed/gator/internal/config"
+)
+
+// maxFeedBytes caps how much of a response body is read, after
+// decompression.  It's set from the config in main.
+var maxFeedBytes int64 = config.DefaultMaxFeedBytes
+
+var (
+	errFeedTooLarge  = errors.New("feed is too large")
+	errFeedTruncated = errors.New("feed was truncated")
+)
+
+type fetchResult struct {
+	StatusCode  int
+	Body        []byte
+	ContentType string
+	// URL is where the body was actually fetched from, after redirects
+	URL string
+	// Moved is set when every redirect on the way to URL was permanent
+	// (301/308), meaning the old URL shouldn't be used anymore
+	Moved bool
+	// RetryAfter is when the server asked us to come back, if it did
+	RetryAfter time.Time
+}
+
+// statusError is returned by fetchURL for responses other than 2xx.
+type statusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	res, body, err := openURL(ctx, feedURL)
+	if err != nil {
+		return &RSSFeed{}, err
+	}
+	defer body.Close()
+	rss, err := parseFeed(body, res.ContentType)
+	if err != nil {
+		return &RSSFeed{}, err
+	}
+	return rss, nil
+}
+
+// fetchURL downloads url into memory, see openURL.
+func fetchURL(ctx context.Context, url string) (*fetchResult, error) {
+	res, body, err := openURL(ctx, url)
+	if err != nil {
+		return res, err
+	}
+	defer body.Close()
+
+	res.Body, err = io.ReadAll(body)
+	if err != nil {
+		return res, fmt.Errorf("Error reading request body:\n%w", err)
+	}
+	return res, nil
+}
+
+// openURL requests url, keeping track of where redirects lead, and returns
+// the decompressed body for streaming.  Reading more than maxFeedBytes from
+// it fails with errFeedTooLarge, and a body cut short fails with
+// errFeedTruncated.  Non-2xx responses return a *statusError along with the
+// result, so callers can still look at the status and Retry-After.
+func openURL(ctx context.Context, url string) (*fetchResult, io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error creating request to %s:\n%w", url, err)
+	}
+	req.Header.Set("User-Agent", "gator")
+	// setting this ourselves turns off Go's transparent gzip, so the
+	// decoding below is done for every encoding
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+
+	permanent := true
+	client := http.Client{
+		Timeout: 10 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if code := req.Response.StatusCode; code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+				permanent = false
+			}
+			return nil
+		},
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error performing request:\n%w", err)
+	}
+
+	finalURL := res.Request.URL.String()
+	result := &fetchResult{
+		StatusCode:  res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+		URL:         finalURL,
+		Moved:       permanent && finalURL != url,
+		RetryAfter:  parseRetryAfter(res.Header.Get("Retry-After")),
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		// the body is an error page, not a feed
+		res.Body.Close()
+		return result, nil, &statusError{URL: finalURL, StatusCode: res.StatusCode}
+	}
+	if res.ContentLength > maxFeedBytes && res.Header.Get("Content-Encoding") == "" {
+		res.Body.Close()
+		return result, nil, fmt.Errorf("%w: %s is %d bytes, the limit is %d", errFeedTooLarge, finalURL, res.ContentLength, maxFeedBytes)
+	}
+
+	decoded, err := decodeBody(res.Body, res.Header.Get("Content-Encoding"))
+	if err != nil {
+		res.Body.Close()
+		return result, nil, fmt.Errorf("%w: %s", err, finalURL)
+	}
+	body := &feedBody{
+		r:         decoded,
+		remaining: maxFeedBytes,
+		closer:    res.Body,
+	}
+	return result, body, nil
+}
+
+// decodeBody undoes the Content-Encodings in header, which are listed in the
+// order they were applied.
+func decodeBody(body io.Reader, header string) (io.Reader, error) {
+	encodings := strings.Split(header, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		switch encoding := strings.ToLower(strings.TrimSpace(encodings[i])); encoding {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			body, err = gzip.NewReader(body)
+		case "deflate":
+			body, err = newDeflateReader(body)
+		case "br":
+			body = brotli.NewReader(body)
+		default:
+			return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, errFeedTruncated
+			}
+			return nil, fmt.Errorf("Error decoding %s body:\n%w", encodings[i], err)
+		}
+	}
+	return body, nil
+}
+
+// newDeflateReader handles "deflate" bodies, which are meant to be zlib
+// wrapped but are sent as raw deflate data by enough servers that both need
+// to work.
+func newDeflateReader(body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	// a zlib header is CMF FLG with CM = 8 and a multiple of 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// feedBody enforces maxFeedBytes on a response body and turns cut off
+// streams into errFeedTruncated.
+type feedBody struct {
+	r         io.Reader
+	remaining int64
+	closer    io.Closer
+}
+
+func (b *feedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// only an error if there's actually more to read
+		var probe [1]byte
+		if n, _ := b.r.Read(probe[:]); n > 0 {
+			return 0, fmt.Errorf("%w: more than %d bytes", errFeedTooLarge, maxFeedBytes)
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.r.Read(p)
+	b.remaining -= int64(n)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = errFeedTruncated
+	}
+	return n, err
+}
+
+func (b *feedBody) Close() error {
+	return b.closer.Close()
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP date.  It returns the zero time if there's none.
+func parseRetryAfter(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/thomas-reed/gator/internal/database"
+)
+
+// filterFields are the parts of a post a filter can look at.  Filters
+// without one look at all of them ("any").
+var filterFields = []string{"title", "description", "author", "category"}
+
+func handlerFilterAdd(s *state, cmd command, user database.User) error {
+	usage := fmt.Sprintf("Usage: gator %s [--feed <feed_url>] --include|--exclude[-title|-description|-author|-category] <pattern> ... [--regex] [--case-sensitive]", cmd.name)
+	_, flags, err := parseFlags(cmd.args, "regex", "case-sensitive")
+	if err != nil {
+		return fmt.Errorf("%w.  %s", err, usage)
+	}
+
+	feedID := uuid.NullUUID{}
+	if flags["feed"] != "" {
+		feed, err := findFeed(context.Background(), s.db, flags["feed"])
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("No feed found with URL %s", flags["feed"])
+		}
+		if err != nil {
+			return fmt.Errorf("Error getting feed from db:\n%w", err)
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	// one filter per --include*/--exclude* option
+	filters := []database.CreateFilterParams{}
+	names := []string{}
+	for name := range flags {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if name == "feed" || name == "regex" || name == "case-sensitive" {
+			continue
+		}
+		action, field, _ := strings.Cut(name, "-")
+		if field == "" {
+			field = "any"
+		}
+		if (action != "include" && action != "exclude") || (field != "any" && !slices.Contains(filterFields, field)) {
+			return fmt.Errorf("Unknown option --%s.  %s", name, usage)
+		}
+		if flags[name] == "" {
+			return fmt.Errorf("Empty pattern for --%s", name)
+		}
+		filters = append(filters, database.CreateFilterParams{
+			UserID:        user.ID,
+			FeedID:        feedID,
+			Action:        action,
+			Field:         field,
+			Pattern:       flags[name],
+			IsRegex:       flags["regex"] != "",
+			CaseSensitive: flags["case-sensitive"] != "",
+		})
+	}
+	if len(filters) == 0 {
+		return fmt.Errorf("At least one --include or --exclude pattern required.  %s", usage)
+	}
+
+	// an invalid regex would break browse, so postgres checks them first
+	for _, filter := range filters {
+		if !filter.IsRegex {
+			continue
+		}
+		if err := s.db.ValidateRegex(context.Background(), filter.Pattern); err != nil {
+			return fmt.Errorf("Invalid regular expression %q:\n%w", filter.Pattern, err)
+		}
+	}
+
+	// all the filters or none of them
+	tx, err := s.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("Error starting transaction:\n%w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+	added := []database.Filter{}
+	for _, params := range filters {
+		filter, err := qtx.CreateFilter(context.Background(), params)
+		if err != nil {
+			return fmt.Errorf("Error adding filter:\n%w", err)
+		}
+		added = append(added, filter)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Error committing transaction:\n%w", err)
+	}
+	for _, filter := range added {
+		fmt.Printf("Added filter #%d: %s %s\n", filter.Seq, filter.Action, describeMatch(filter.Field, filter.Pattern, filter.IsRegex, filter.CaseSensitive))
+	}
+	return nil
+}
+
+func handlerFilterList(s *state, cmd command, user database.User) error {
+	filters, err := s.db.GetFiltersForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting filters from db:\n%w", err)
+	}
+	if len(filters) == 0 {
+		fmt.Println("No filters set.  Usage: gator filter add [--feed <feed_url>] --exclude <pattern>")
+		return nil
+	}
+	fmt.Println("browse hides posts matching an exclude filter, and for feeds with include filters, posts that don't match one of them:")
+	for _, filter := range filters {
+		feed := "all feeds"
+		if filter.FeedName.Valid {
+			feed = fmt.Sprintf("'%s' (%s)", filter.FeedName.String, filter.FeedUrl.String)
+		}
+		fmt.Printf("#%d  %s %s - on %s\n", filter.Seq, filter.Action, describeMatch(filter.Field, filter.Pattern, filter.IsRegex, filter.CaseSensitive), feed)
+	}
+	return nil
+}
+
+func handlerFilterRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf("Filter ID required.  Usage: gator %s <filter_id>", cmd.name)
+	}
+	seq, err := strconv.ParseInt(strings.TrimPrefix(cmd.args[0], "#"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid filter ID %s - see gator filter list", cmd.args[0])
+	}
+	removed, err := s.db.DeleteFilter(context.Background(), database.DeleteFilterParams{
+		UserID: user.ID,
+		Seq:    seq,
+	})
+	if err != nil {
+		return fmt.Errorf("Error removing filter:\n%w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("You have no filter #%d - see gator filter list", seq)
+	}
+	fmt.Printf("Filter #%d removed\n", seq)
+	return nil
+}
+
+// describeMatch reads a filter or rule's condition back as words, e.g.
+// `posts with "sponsored" in the title`.
+func describeMatch(field, pattern string, isRegex, caseSensitive bool) string {
+	match := fmt.Sprintf("%q", pattern)
+	if isRegex {
+		match = "/" + pattern + "/"
+	}
+	where := "anywhere"
+	if field != "any" {
+		where = "in the " + field
+	}
+	description := fmt.Sprintf("posts with %s %s", match, where)
+	if caseSensitive {
+		description += " (case sensitive)"
+	}
+	return description
+}
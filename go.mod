@@ -3,6 +3,13 @@ module github.com/thomas-reed/gator
 go 1.25.5
 
 require (
+	github.com/andybalholm/brotli v1.2.0
 	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.45.0
+	golang.org/x/net v0.47.0
+	golang.org/x/term v0.37.0
+	golang.org/x/text v0.31.0
 )
+
+require golang.org/x/sys v0.38.0 // indirect
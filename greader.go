@@ -0,0 +1,766 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thomas-reed/gator/internal/database"
+)
+
+// Google Reader API compatibility layer.  Clients authenticate with their
+// gator username and an API key generated by `gator apikey`.
+
+const (
+	readerStatePrefix  = "user/-/state/com.google/"
+	readerReadingList  = readerStatePrefix + "reading-list"
+	readerRead         = readerStatePrefix + "read"
+	readerStarred      = readerStatePrefix + "starred"
+	readerLabelPrefix  = "user/-/label/"
+	readerFeedPrefix   = "feed/"
+	readerItemPrefix   = "tag:google.com,2005:reader/item/"
+	readerDefaultCount = 20
+	readerMaxCount     = 1000
+)
+
+type readerLink struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type readerContent struct {
+	Direction string `json:"direction"`
+	Content   string `json:"content"`
+}
+
+type readerOrigin struct {
+	StreamID string `json:"streamId"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"htmlUrl"`
+}
+
+type readerItem struct {
+	ID            string        `json:"id"`
+	CrawlTimeMsec string        `json:"crawlTimeMsec"`
+	TimestampUsec string        `json:"timestampUsec"`
+	Published     int64         `json:"published"`
+	Updated       int64         `json:"updated"`
+	Title         string        `json:"title"`
+	Author        string        `json:"author,omitempty"`
+	Canonical     []readerLink  `json:"canonical"`
+	Alternate     []readerLink  `json:"alternate"`
+	Summary       readerContent `json:"summary"`
+	Enclosure     []readerLink  `json:"enclosure,omitempty"`
+	Categories    []string      `json:"categories"`
+	Origin        readerOrigin  `json:"origin"`
+}
+
+type readerCategory struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type readerSubscription struct {
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	Categories []readerCategory `json:"categories"`
+	URL        string           `json:"url"`
+	HTMLURL    string           `json:"htmlUrl"`
+	IconURL    string           `json:"iconUrl"`
+}
+
+func registerReaderAPI(s *state, mux *http.ServeMux) {
+	mux.HandleFunc("/accounts/ClientLogin", func(w http.ResponseWriter, r *http.Request) {
+		handlerReaderLogin(s, w, r)
+	})
+	mux.HandleFunc("GET /reader/api/0/token", readerAuth(s, handlerReaderToken))
+	mux.HandleFunc("GET /reader/api/0/user-info", readerAuth(s, handlerReaderUserInfo))
+	mux.HandleFunc("GET /reader/api/0/subscription/list", readerAuth(s, handlerReaderSubscriptionList))
+	mux.HandleFunc("POST /reader/api/0/subscription/edit", readerAuth(s, handlerReaderSubscriptionEdit))
+	mux.HandleFunc("POST /reader/api/0/subscription/quickadd", readerAuth(s, handlerReaderQuickAdd))
+	mux.HandleFunc("GET /reader/api/0/tag/list", readerAuth(s, handlerReaderTagList))
+	mux.HandleFunc("GET /reader/api/0/unread-count", readerAuth(s, handlerReaderUnreadCount))
+	mux.HandleFunc("/reader/api/0/stream/contents", readerAuth(s, handlerReaderStreamContents))
+	mux.HandleFunc("/reader/api/0/stream/contents/{stream...}", readerAuth(s, handlerReaderStreamContents))
+	mux.HandleFunc("/reader/api/0/stream/items/ids", readerAuth(s, handlerReaderItemIDs))
+	mux.HandleFunc("POST /reader/api/0/stream/items/contents", readerAuth(s, handlerReaderItemContents))
+	mux.HandleFunc("POST /reader/api/0/edit-tag", readerAuth(s, handlerReaderEditTag))
+	mux.HandleFunc("POST /reader/api/0/mark-all-as-read", readerAuth(s, handlerReaderMarkAllRead))
+}
+
+func readerAuth(s *state, handler func(s *state, w http.ResponseWriter, r *http.Request, user database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+		if !found || token == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		user, err := s.db.GetUserByAPIKeyHash(r.Context(), sql.NullString{
+			String: hashToken(token),
+			Valid:  true,
+		})
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(s, w, r, user)
+	}
+}
+
+func handlerReaderLogin(s *state, w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	username := strings.ToLower(r.Form.Get("Email"))
+	key := r.Form.Get("Passwd")
+
+	user, err := s.db.GetUserByName(r.Context(), username)
+	if err != nil || !user.ApiKeyHash.Valid || key == "" {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(key)), []byte(user.ApiKeyHash.String)) != 1 {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "SID=%s\nLSID=null\nAuth=%s\n", key, key)
+}
+
+func handlerReaderToken(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	// every request is authenticated by its Authorization header, so the
+	// edit token is only handed out for clients that insist on sending one
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, strings.ReplaceAll(user.ID.String(), "-", ""))
+}
+
+func handlerReaderUserInfo(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"userId":        user.ID.String(),
+		"userName":      user.Name,
+		"userProfileId": user.ID.String(),
+		"userEmail":     "",
+	})
+}
+
+func handlerReaderSubscriptionList(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := s.db.GetFeedFollowsByUser(r.Context(), user.ID)
+	if err != nil {
+		writeServerError(w, "Error getting user follows", err)
+		return
+	}
+	subscriptions := make([]readerSubscription, 0, len(follows))
+	for _, follow := range follows {
+		// a follow's tags are its folders
+		categories := make([]readerCategory, 0, len(follow.Tags))
+		for _, tag := range follow.Tags {
+			categories = append(categories, readerCategory{
+				ID:    readerLabelPrefix + tag,
+				Label: tag,
+			})
+		}
+		subscriptions = append(subscriptions, readerSubscription{
+			ID:         readerFeedPrefix + follow.FeedUrl,
+			Title:      follow.FeedName,
+			Categories: categories,
+			URL:        follow.FeedUrl,
+			HTMLURL:    follow.FeedUrl,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string][]readerSubscription{
+		"subscriptions": subscriptions,
+	})
+}
+
+func handlerReaderSubscriptionEdit(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	for _, streamID := range r.Form["s"] {
+		feedURL, found := strings.CutPrefix(streamID, readerFeedPrefix)
+		if !found {
+			http.Error(w, "Unsupported stream: "+streamID, http.StatusBadRequest)
+			return
+		}
+		switch r.Form.Get("ac") {
+		case "subscribe":
+			feed, err := subscribeFeed(r.Context(), s, user, feedURL, r.Form.Get("t"))
+			if err != nil {
+				writeServerError(w, "Error subscribing to feed", err)
+				return
+			}
+			if err := readerEditLabels(s, r, user, feed); err != nil {
+				writeServerError(w, "Error saving labels", err)
+				return
+			}
+		case "unsubscribe":
+			feed, err := s.db.GetFeedByURL(r.Context(), feedURL)
+			if err != nil {
+				http.Error(w, "Feed not found", http.StatusNotFound)
+				return
+			}
+			if err := s.db.DeleteFeedFollowByUserAndName(r.Context(), database.DeleteFeedFollowByUserAndNameParams{
+				UserID: user.ID,
+				FeedID: feed.ID,
+			}); err != nil {
+				writeServerError(w, "Error deleting feed follow", err)
+				return
+			}
+		case "edit":
+			feed, err := s.db.GetFeedByURL(r.Context(), feedURL)
+			if err != nil {
+				http.Error(w, "Feed not found", http.StatusNotFound)
+				return
+			}
+			// renames only change the title this user sees
+			if _, ok := r.Form["t"]; ok {
+				title := nullString(r.Form.Get("t"))
+				if title.String == feed.Name {
+					title = sql.NullString{}
+				}
+				if err := s.db.SetFeedFollowTitle(r.Context(), database.SetFeedFollowTitleParams{
+					UserID: user.ID,
+					FeedID: feed.ID,
+					Title:  title,
+				}); err != nil {
+					writeServerError(w, "Error saving title", err)
+					return
+				}
+			}
+			if err := readerEditLabels(s, r, user, feed); err != nil {
+				writeServerError(w, "Error saving labels", err)
+				return
+			}
+		default:
+			http.Error(w, "Unsupported action: "+r.Form.Get("ac"), http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "OK")
+}
+
+func handlerReaderQuickAdd(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	feedURL := strings.TrimPrefix(r.Form.Get("quickadd"), readerFeedPrefix)
+	if feedURL == "" {
+		http.Error(w, "quickadd is required", http.StatusBadRequest)
+		return
+	}
+	feed, err := subscribeFeed(r.Context(), s, user, feedURL, "")
+	if err != nil {
+		writeServerError(w, "Error subscribing to feed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"numResults": 1,
+		"query":      feedURL,
+		"streamId":   readerFeedPrefix + feed.Url,
+		"streamName": feed.Name,
+	})
+}
+
+// subscribeFeed follows the feed at feedURL, adding it to the database
+// first (without fetching it - agg will) if nobody has added it yet.
+func subscribeFeed(ctx context.Context, s *state, user database.User, feedURL, title string) (database.Feed, error) {
+	feedURL, err := normalizeFeedURL(feedURL)
+	if err != nil {
+		return database.Feed{}, err
+	}
+	feed, err := findFeed(ctx, s.db, feedURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		if title == "" {
+			title = feedURL
+		}
+		feed, err = s.db.CreateFeed(ctx, database.CreateFeedParams{
+			Name:   title,
+			Url:    feedURL,
+			UserID: user.ID,
+		})
+	}
+	if err != nil {
+		return database.Feed{}, err
+	}
+	_, err = s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if err != nil && !isUniqueViolation(err) {
+		return database.Feed{}, err
+	}
+	return feed, nil
+}
+
+// readerEditLabels applies the labels a subscription/edit request adds (a)
+// and removes (r) to the user's tags for feed.
+func readerEditLabels(s *state, r *http.Request, user database.User, feed database.Feed) error {
+	if len(r.Form["a"]) == 0 && len(r.Form["r"]) == 0 {
+		return nil
+	}
+	follow, err := s.db.GetFeedFollow(r.Context(), database.GetFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		return err
+	}
+	tags := append([]string{}, follow.Tags...)
+	for _, label := range r.Form["r"] {
+		label = strings.TrimPrefix(normalizeReaderStream(label), readerLabelPrefix)
+		tags = slices.DeleteFunc(tags, func(tag string) bool {
+			return strings.EqualFold(tag, label)
+		})
+	}
+	for _, label := range r.Form["a"] {
+		label, found := strings.CutPrefix(normalizeReaderStream(label), readerLabelPrefix)
+		if !found {
+			continue
+		}
+		tag, err := normalizeTag(label)
+		if err != nil {
+			return err
+		}
+		tags = addTag(tags, tag)
+	}
+	return s.db.SetFeedFollowTags(r.Context(), database.SetFeedFollowTagsParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+		Tags:   tags,
+	})
+}
+
+func handlerReaderTagList(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := s.db.GetFeedFollowsByUser(r.Context(), user.ID)
+	if err != nil {
+		writeServerError(w, "Error getting user follows", err)
+		return
+	}
+	tags := []map[string]string{
+		{"id": readerStarred},
+	}
+	seen := map[string]bool{}
+	for _, follow := range follows {
+		for _, tag := range follow.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, map[string]string{
+					"id":   readerLabelPrefix + tag,
+					"type": "folder",
+				})
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string][]map[string]string{
+		"tags": tags,
+	})
+}
+
+func handlerReaderUnreadCount(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	counts, err := s.db.GetUnreadCountsForUser(r.Context(), user.ID)
+	if err != nil {
+		writeServerError(w, "Error getting unread counts", err)
+		return
+	}
+	unreadCounts := make([]map[string]any, 0, len(counts)+1)
+	var total int64
+	var newest time.Time
+	for _, count := range counts {
+		total += count.UnreadCount
+		if count.NewestItem.After(newest) {
+			newest = count.NewestItem
+		}
+		unreadCounts = append(unreadCounts, readerUnreadCount(readerFeedPrefix+count.FeedUrl, count.UnreadCount, count.NewestItem))
+	}
+	unreadCounts = append(unreadCounts, readerUnreadCount(readerReadingList, total, newest))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"max":          readerMaxCount,
+		"unreadcounts": unreadCounts,
+	})
+}
+
+// readerUnreadCount is one entry of unread-count, leaving out the newest
+// item's time when there are no items rather than reporting 1970.
+func readerUnreadCount(id string, count int64, newest time.Time) map[string]any {
+	entry := map[string]any{
+		"id":    id,
+		"count": count,
+	}
+	if !newest.IsZero() {
+		entry["newestItemTimestampUsec"] = strconv.FormatInt(newest.UnixMicro(), 10)
+	}
+	return entry
+}
+
+func handlerReaderStreamContents(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	streamID := r.PathValue("stream")
+	if streamID == "" {
+		streamID = r.FormValue("s")
+	}
+	params, err := readerItemsParams(s, r, user, streamID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	posts, err := s.db.GetReaderItems(r.Context(), params)
+	if err != nil {
+		writeServerError(w, "Error getting posts", err)
+		return
+	}
+
+	items := make([]readerItem, 0, len(posts))
+	for _, post := range posts {
+		items = append(items, newReaderItem(post))
+	}
+	response := map[string]any{
+		"direction": "ltr",
+		"id":        streamID,
+		"title":     streamID,
+		"updated":   time.Now().Unix(),
+		"items":     items,
+	}
+	if len(posts) == int(params.RowLimit) {
+		response["continuation"] = strconv.Itoa(int(params.RowOffset + params.RowLimit))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handlerReaderItemIDs(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	params, err := readerItemsParams(s, r, user, r.FormValue("s"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	posts, err := s.db.GetReaderItems(r.Context(), params)
+	if err != nil {
+		writeServerError(w, "Error getting posts", err)
+		return
+	}
+
+	itemRefs := make([]map[string]any, 0, len(posts))
+	for _, post := range posts {
+		itemRefs = append(itemRefs, map[string]any{
+			"id":              strconv.FormatInt(post.Seq, 10),
+			"directStreamIds": []string{},
+			"timestampUsec":   strconv.FormatInt(readerPostTime(post).UnixMicro(), 10),
+		})
+	}
+	response := map[string]any{
+		"itemRefs": itemRefs,
+	}
+	if len(posts) == int(params.RowLimit) {
+		response["continuation"] = strconv.Itoa(int(params.RowOffset + params.RowLimit))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handlerReaderItemContents(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	seqs, err := parseReaderItemIDs(r.Form["i"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items := []readerItem{}
+	if len(seqs) > 0 {
+		posts, err := s.db.GetReaderItems(r.Context(), database.GetReaderItemsParams{
+			UserID:   user.ID,
+			Seqs:     seqs,
+			RowLimit: int32(len(seqs)),
+		})
+		if err != nil {
+			writeServerError(w, "Error getting posts", err)
+			return
+		}
+		for _, post := range posts {
+			items = append(items, newReaderItem(post))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"direction": "ltr",
+		"id":        readerReadingList,
+		"updated":   time.Now().Unix(),
+		"items":     items,
+	})
+}
+
+func handlerReaderEditTag(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	seqs, err := parseReaderItemIDs(r.Form["i"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// only posts from the user's own feeds, so other feeds' posts can't be
+	// given read or starred states
+	postIDs, err := s.db.GetPostIDsBySeqs(r.Context(), database.GetPostIDsBySeqsParams{
+		UserID: user.ID,
+		Seqs:   seqs,
+	})
+	if err != nil {
+		writeServerError(w, "Error getting posts", err)
+		return
+	}
+
+	for _, postID := range postIDs {
+		for _, tag := range r.Form["a"] {
+			if err := setReaderTag(s, r, user, postID, tag, true); err != nil {
+				writeServerError(w, "Error updating post state", err)
+				return
+			}
+		}
+		for _, tag := range r.Form["r"] {
+			if err := setReaderTag(s, r, user, postID, tag, false); err != nil {
+				writeServerError(w, "Error updating post state", err)
+				return
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "OK")
+}
+
+func setReaderTag(s *state, r *http.Request, user database.User, postID uuid.UUID, tag string, value bool) error {
+	switch normalizeReaderStream(tag) {
+	case readerRead:
+		return s.db.SetPostRead(r.Context(), database.SetPostReadParams{
+			UserID: user.ID,
+			PostID: postID,
+			IsRead: value,
+		})
+	case readerStatePrefix + "kept-unread":
+		return s.db.SetPostRead(r.Context(), database.SetPostReadParams{
+			UserID: user.ID,
+			PostID: postID,
+			IsRead: !value,
+		})
+	case readerStarred:
+		return s.db.SetPostStarred(r.Context(), database.SetPostStarredParams{
+			UserID:    user.ID,
+			PostID:    postID,
+			IsStarred: value,
+		})
+	}
+	// other tags have no equivalent in gator
+	return nil
+}
+
+func handlerReaderMarkAllRead(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	params := database.MarkAllPostsReadParams{
+		UserID: user.ID,
+		Before: time.Now().UTC(),
+	}
+	if ts := r.Form.Get("ts"); ts != "" {
+		usec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid ts", http.StatusBadRequest)
+			return
+		}
+		params.Before = time.UnixMicro(usec).UTC()
+	}
+	streamID := normalizeReaderStream(r.Form.Get("s"))
+	if feedURL, found := strings.CutPrefix(streamID, readerFeedPrefix); found {
+		feed, err := s.db.GetFeedByURL(r.Context(), feedURL)
+		if err != nil {
+			http.Error(w, "Feed not found", http.StatusNotFound)
+			return
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	} else if label, found := strings.CutPrefix(streamID, readerLabelPrefix); found {
+		params.Label = nullString(label)
+	} else if streamID != readerReadingList {
+		http.Error(w, "Unsupported stream: "+streamID, http.StatusBadRequest)
+		return
+	}
+	if err := s.db.MarkAllPostsRead(r.Context(), params); err != nil {
+		writeServerError(w, "Error marking posts read", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "OK")
+}
+
+// readerItemsParams translates a stream ID and the common stream query
+// parameters (n, c, xt, it, ot, nt, r) into a GetReaderItems query.
+func readerItemsParams(s *state, r *http.Request, user database.User, streamID string) (database.GetReaderItemsParams, error) {
+	params := database.GetReaderItemsParams{
+		UserID:   user.ID,
+		RowLimit: readerDefaultCount,
+	}
+
+	applyStream := func(streamID string) error {
+		switch streamID = normalizeReaderStream(streamID); {
+		case streamID == "" || streamID == readerReadingList:
+		case streamID == readerRead:
+			params.OnlyRead = true
+		case streamID == readerStarred:
+			params.OnlyStarred = true
+		case strings.HasPrefix(streamID, readerFeedPrefix):
+			feed, err := s.db.GetFeedByURL(r.Context(), strings.TrimPrefix(streamID, readerFeedPrefix))
+			if err != nil {
+				return fmt.Errorf("Feed not found: %s", streamID)
+			}
+			params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		case strings.HasPrefix(streamID, readerLabelPrefix):
+			params.Label = nullString(strings.TrimPrefix(streamID, readerLabelPrefix))
+		default:
+			return fmt.Errorf("Unsupported stream: %s", streamID)
+		}
+		return nil
+	}
+	if err := applyStream(streamID); err != nil {
+		return params, err
+	}
+	if include := r.FormValue("it"); include != "" {
+		if err := applyStream(include); err != nil {
+			return params, err
+		}
+	}
+	if normalizeReaderStream(r.FormValue("xt")) == readerRead {
+		params.ExcludeRead = true
+	}
+
+	if n := r.FormValue("n"); n != "" {
+		count, err := strconv.Atoi(n)
+		if err != nil || count < 1 {
+			return params, fmt.Errorf("Invalid n: %s", n)
+		}
+		params.RowLimit = int32(min(count, readerMaxCount))
+	}
+	if c := r.FormValue("c"); c != "" {
+		offset, err := strconv.Atoi(c)
+		if err != nil || offset < 0 {
+			return params, fmt.Errorf("Invalid continuation: %s", c)
+		}
+		params.RowOffset = int32(offset)
+	}
+	if ot := r.FormValue("ot"); ot != "" {
+		sec, err := strconv.ParseInt(ot, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("Invalid ot: %s", ot)
+		}
+		params.OlderThan = sql.NullTime{Time: time.Unix(sec, 0).UTC(), Valid: true}
+	}
+	if nt := r.FormValue("nt"); nt != "" {
+		sec, err := strconv.ParseInt(nt, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("Invalid nt: %s", nt)
+		}
+		params.NewerThan = sql.NullTime{Time: time.Unix(sec, 0).UTC(), Valid: true}
+	}
+	params.OldestFirst = r.FormValue("r") == "o"
+	return params, nil
+}
+
+// normalizeReaderStream rewrites user-specific stream IDs such as
+// user/1234/state/com.google/read to the user/-/ form.
+func normalizeReaderStream(streamID string) string {
+	if !strings.HasPrefix(streamID, "user/") {
+		return streamID
+	}
+	parts := strings.SplitN(streamID, "/", 3)
+	if len(parts) < 3 {
+		return streamID
+	}
+	return "user/-/" + parts[2]
+}
+
+// parseReaderItemIDs accepts item IDs in either the long
+// tag:google.com,2005:reader/item/<hex> form or the short decimal form.
+func parseReaderItemIDs(ids []string) ([]int64, error) {
+	seqs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if hexID, found := strings.CutPrefix(id, readerItemPrefix); found {
+			seq, err := strconv.ParseUint(hexID, 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid item id: %s", id)
+			}
+			seqs = append(seqs, int64(seq))
+			continue
+		}
+		seq, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid item id: %s", id)
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs, nil
+}
+
+func readerPostTime(post database.GetReaderItemsRow) time.Time {
+	if post.PublishedAt.Valid {
+		return post.PublishedAt.Time
+	}
+	return post.CreatedAt
+}
+
+func newReaderItem(post database.GetReaderItemsRow) readerItem {
+	published := readerPostTime(post)
+	categories := []string{readerReadingList}
+	if post.IsRead {
+		categories = append(categories, readerRead)
+	}
+	if post.IsStarred {
+		categories = append(categories, readerStarred)
+	}
+	// clients show the summary as the article body, so prefer the full content
+	body := post.Description.String
+	if post.Content.Valid {
+		body = post.Content.String
+	}
+	enclosure := []readerLink{}
+	if post.ImageUrl.Valid {
+		imagePath, _, _ := strings.Cut(post.ImageUrl.String, "?")
+		imageType := mime.TypeByExtension(path.Ext(imagePath))
+		if !strings.HasPrefix(imageType, "image/") {
+			imageType = "image/jpeg"
+		}
+		enclosure = append(enclosure, readerLink{Href: post.ImageUrl.String, Type: imageType})
+	}
+	return readerItem{
+		ID:            fmt.Sprintf("%s%016x", readerItemPrefix, post.Seq),
+		CrawlTimeMsec: strconv.FormatInt(post.CreatedAt.UnixMilli(), 10),
+		TimestampUsec: strconv.FormatInt(published.UnixMicro(), 10),
+		Published:     published.Unix(),
+		Updated:       post.UpdatedAt.Unix(),
+		Title:         post.Title,
+		Author:        post.Author.String,
+		Canonical:     []readerLink{{Href: post.Url}},
+		Alternate:     []readerLink{{Href: post.Url, Type: "text/html"}},
+		Summary: readerContent{
+			Direction: "ltr",
+			Content:   body,
+		},
+		Enclosure:  enclosure,
+		Categories: categories,
+		Origin: readerOrigin{
+			StreamID: readerFeedPrefix + post.FeedUrl,
+			Title:    post.FeedName,
+			HTMLURL:  post.FeedUrl,
+		},
+	}
+}
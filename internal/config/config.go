@@ -8,13 +8,30 @@ import (
 
 const configFilename = ".gatorconfig.json"
 
+// DefaultMaxFeedBytes is used when the config doesn't set max_feed_bytes
+const DefaultMaxFeedBytes = 10 << 20
+
 type Config struct {
-	DbURL string `json:"db_url"`
+	DbURL           string `json:"db_url"`
 	CurrentUsername string `json:"current_user_name"`
+	SessionToken    string `json:"session_token,omitempty"`
+	MaxFeedBytes    int64  `json:"max_feed_bytes,omitempty"`
+	// NotifyCommand is run by sh when a notify rule matches a new post
+	NotifyCommand string `json:"notify_command,omitempty"`
+	// LastBrowse holds the IDs of the posts the last browse showed, in order
+	LastBrowse []int64 `json:"last_browse,omitempty"`
 }
 
-func (c *Config) SetUser(username string) error {
+func (c *Config) SetUser(username, sessionToken string) error {
 	c.CurrentUsername = username
+	c.SessionToken = sessionToken
+	// another user's listing
+	c.LastBrowse = nil
+	return write(*c)
+}
+
+func (c *Config) SetLastBrowse(postIDs []int64) error {
+	c.LastBrowse = postIDs
 	return write(*c)
 }
 
@@ -7,11 +7,37 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countFeedFollowsForUser = `-- name: CountFeedFollowsForUser :one
+SELECT COUNT(*) FROM feed_follows
+WHERE user_id = $1
+`
+
+func (q *Queries) CountFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFeedFollowsForUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countFollowersForFeed = `-- name: CountFollowersForFeed :one
+SELECT COUNT(*) FROM feed_follows
+WHERE feed_id = $1
+`
+
+func (q *Queries) CountFollowersForFeed(ctx context.Context, feedID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFollowersForFeed, feedID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
   INSERT INTO feed_follows (id, user_id, feed_id, created_at, updated_at)
@@ -22,10 +48,10 @@ WITH inserted_feed_follow AS (
     NOW() AT TIME ZONE 'UTC',
     NOW() AT TIME ZONE 'UTC'
   )
-  RETURNING id, created_at, updated_at, user_id, feed_id
+  RETURNING id, created_at, updated_at, user_id, feed_id, keep_episodes, tags, title
 )
 SELECT
-  inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+  inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.keep_episodes, inserted_feed_follow.tags, inserted_feed_follow.title,
   feeds.name AS feed_name,
   users.name AS user_name
 FROM inserted_feed_follow
@@ -39,13 +65,16 @@ type CreateFeedFollowParams struct {
 }
 
 type CreateFeedFollowRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uuid.UUID
+	FeedID       uuid.UUID
+	KeepEpisodes sql.NullInt32
+	Tags         []string
+	Title        sql.NullString
+	FeedName     string
+	UserName     string
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
@@ -57,6 +86,9 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.KeepEpisodes,
+		pq.Array(&i.Tags),
+		&i.Title,
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -78,10 +110,39 @@ func (q *Queries) DeleteFeedFollowByUserAndName(ctx context.Context, arg DeleteF
 	return err
 }
 
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT id, created_at, updated_at, user_id, feed_id, keep_episodes, tags, title FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type GetFeedFollowParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.KeepEpisodes,
+		pq.Array(&i.Tags),
+		&i.Title,
+	)
+	return i, err
+}
+
 const getFeedFollowsByUser = `-- name: GetFeedFollowsByUser :many
 SELECT
   users.name AS user_name,
-  feeds.name AS feed_name
+  COALESCE(feed_follows.title, feeds.name) AS feed_name,
+  feeds.id AS feed_id,
+  feeds.url AS feed_url,
+  feed_follows.tags
 FROM feed_follows
 INNER JOIN users ON feed_follows.user_id = users.id
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
@@ -91,6 +152,9 @@ WHERE users.id = $1
 type GetFeedFollowsByUserRow struct {
 	UserName string
 	FeedName string
+	FeedID   uuid.UUID
+	FeedUrl  string
+	Tags     []string
 }
 
 func (q *Queries) GetFeedFollowsByUser(ctx context.Context, id uuid.UUID) ([]GetFeedFollowsByUserRow, error) {
@@ -102,7 +166,13 @@ func (q *Queries) GetFeedFollowsByUser(ctx context.Context, id uuid.UUID) ([]Get
 	var items []GetFeedFollowsByUserRow
 	for rows.Next() {
 		var i GetFeedFollowsByUserRow
-		if err := rows.Scan(&i.UserName, &i.FeedName); err != nil {
+		if err := rows.Scan(
+			&i.UserName,
+			&i.FeedName,
+			&i.FeedID,
+			&i.FeedUrl,
+			pq.Array(&i.Tags),
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -115,3 +185,117 @@ func (q *Queries) GetFeedFollowsByUser(ctx context.Context, id uuid.UUID) ([]Get
 	}
 	return items, nil
 }
+
+const getNextFeedOwner = `-- name: GetNextFeedOwner :one
+SELECT users.id, users.name FROM feed_follows
+INNER JOIN users ON feed_follows.user_id = users.id
+WHERE feed_follows.feed_id = $1 AND feed_follows.user_id <> $2
+ORDER BY feed_follows.created_at ASC
+LIMIT 1
+`
+
+type GetNextFeedOwnerParams struct {
+	FeedID uuid.UUID
+	UserID uuid.UUID
+}
+
+type GetNextFeedOwnerRow struct {
+	ID   uuid.UUID
+	Name string
+}
+
+func (q *Queries) GetNextFeedOwner(ctx context.Context, arg GetNextFeedOwnerParams) (GetNextFeedOwnerRow, error) {
+	row := q.db.QueryRowContext(ctx, getNextFeedOwner, arg.FeedID, arg.UserID)
+	var i GetNextFeedOwnerRow
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (id, user_id, feed_id, keep_episodes, tags, title, created_at, updated_at)
+SELECT
+  gen_random_uuid(),
+  user_id,
+  $1::uuid,
+  keep_episodes,
+  tags,
+  title,
+  created_at,
+  NOW() AT TIME ZONE 'UTC'
+FROM feed_follows
+WHERE feed_id = $2
+ON CONFLICT (user_id, feed_id) DO UPDATE
+SET
+  tags = ARRAY(
+    SELECT DISTINCT unnest(feed_follows.tags || EXCLUDED.tags)
+    ORDER BY 1
+  ),
+  title = COALESCE(feed_follows.title, EXCLUDED.title)
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const setFeedFollowKeepEpisodes = `-- name: SetFeedFollowKeepEpisodes :exec
+UPDATE feed_follows
+SET
+  keep_episodes = $3,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type SetFeedFollowKeepEpisodesParams struct {
+	UserID       uuid.UUID
+	FeedID       uuid.UUID
+	KeepEpisodes sql.NullInt32
+}
+
+func (q *Queries) SetFeedFollowKeepEpisodes(ctx context.Context, arg SetFeedFollowKeepEpisodesParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowKeepEpisodes, arg.UserID, arg.FeedID, arg.KeepEpisodes)
+	return err
+}
+
+const setFeedFollowTags = `-- name: SetFeedFollowTags :exec
+UPDATE feed_follows
+SET
+  tags = $3,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type SetFeedFollowTagsParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+	Tags   []string
+}
+
+func (q *Queries) SetFeedFollowTags(ctx context.Context, arg SetFeedFollowTagsParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowTags, arg.UserID, arg.FeedID, pq.Array(arg.Tags))
+	return err
+}
+
+const setFeedFollowTitle = `-- name: SetFeedFollowTitle :exec
+UPDATE feed_follows
+SET
+  title = $3,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type SetFeedFollowTitleParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+	Title  sql.NullString
+}
+
+func (q *Queries) SetFeedFollowTitle(ctx context.Context, arg SetFeedFollowTitleParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowTitle, arg.UserID, arg.FeedID, arg.Title)
+	return err
+}
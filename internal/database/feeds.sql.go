@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -21,7 +22,7 @@ VALUES (
   NOW() AT TIME ZONE 'UTC',
   NOW() AT TIME ZONE 'UTC'
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, dead_at, retry_after, fetch_full_text
 `
 
 type CreateFeedParams struct {
@@ -41,12 +42,25 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.DeadAt,
+		&i.RetryAfter,
+		&i.FetchFullText,
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, dead_at, retry_after, fetch_full_text FROM feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
@@ -60,12 +74,56 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.DeadAt,
+		&i.RetryAfter,
+		&i.FetchFullText,
 	)
 	return i, err
 }
 
+const getFeedsByUser = `-- name: GetFeedsByUser :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, dead_at, retry_after, fetch_full_text FROM feeds
+WHERE user_id = $1
+`
+
+func (q *Queries) GetFeedsByUser(ctx context.Context, userID uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.DeadAt,
+			&i.RetryAfter,
+			&i.FetchFullText,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, dead_at, retry_after, fetch_full_text FROM feeds
+WHERE dead_at IS NULL
+  AND (retry_after IS NULL OR retry_after <= NOW() AT TIME ZONE 'UTC')
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1
 `
@@ -81,6 +139,9 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.DeadAt,
+		&i.RetryAfter,
+		&i.FetchFullText,
 	)
 	return i, err
 }
@@ -89,7 +150,8 @@ const listFeeds = `-- name: ListFeeds :many
 SELECT
   feeds.name AS feed_name,
   feeds.url AS feed_url,
-  users.name AS user_name
+  users.name AS user_name,
+  feeds.dead_at
 FROM feeds
 INNER JOIN users ON feeds.user_id = users.id
 `
@@ -98,6 +160,7 @@ type ListFeedsRow struct {
 	FeedName string
 	FeedUrl  string
 	UserName string
+	DeadAt   sql.NullTime
 }
 
 func (q *Queries) ListFeeds(ctx context.Context) ([]ListFeedsRow, error) {
@@ -109,7 +172,12 @@ func (q *Queries) ListFeeds(ctx context.Context) ([]ListFeedsRow, error) {
 	var items []ListFeedsRow
 	for rows.Next() {
 		var i ListFeedsRow
-		if err := rows.Scan(&i.FeedName, &i.FeedUrl, &i.UserName); err != nil {
+		if err := rows.Scan(
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.UserName,
+			&i.DeadAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -123,13 +191,27 @@ func (q *Queries) ListFeeds(ctx context.Context) ([]ListFeedsRow, error) {
 	return items, nil
 }
 
+const markFeedDead = `-- name: MarkFeedDead :exec
+UPDATE feeds
+SET
+  dead_at = NOW() AT TIME ZONE 'UTC',
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+`
+
+func (q *Queries) MarkFeedDead(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markFeedDead, id)
+	return err
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :one
 UPDATE feeds
 SET
   last_fetched_at = NOW() AT TIME ZONE 'UTC',
+  retry_after = NULL,
   updated_at = NOW() AT TIME ZONE 'UTC'
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, dead_at, retry_after, fetch_full_text
 `
 
 func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
@@ -143,6 +225,138 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.DeadAt,
+		&i.RetryAfter,
+		&i.FetchFullText,
+	)
+	return i, err
+}
+
+const renameFeed = `-- name: RenameFeed :one
+UPDATE feeds
+SET
+  name = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, dead_at, retry_after, fetch_full_text
+`
+
+type RenameFeedParams struct {
+	ID   uuid.UUID
+	Name string
+}
+
+func (q *Queries) RenameFeed(ctx context.Context, arg RenameFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, renameFeed, arg.ID, arg.Name)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.DeadAt,
+		&i.RetryAfter,
+		&i.FetchFullText,
+	)
+	return i, err
+}
+
+const resetFeeds = `-- name: ResetFeeds :exec
+DELETE FROM feeds
+`
+
+func (q *Queries) ResetFeeds(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetFeeds)
+	return err
+}
+
+const setFeedFetchFullText = `-- name: SetFeedFetchFullText :exec
+UPDATE feeds
+SET
+  fetch_full_text = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+`
+
+type SetFeedFetchFullTextParams struct {
+	ID            uuid.UUID
+	FetchFullText bool
+}
+
+func (q *Queries) SetFeedFetchFullText(ctx context.Context, arg SetFeedFetchFullTextParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchFullText, arg.ID, arg.FetchFullText)
+	return err
+}
+
+const setFeedOwner = `-- name: SetFeedOwner :exec
+UPDATE feeds
+SET
+  user_id = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+`
+
+type SetFeedOwnerParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) SetFeedOwner(ctx context.Context, arg SetFeedOwnerParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedOwner, arg.ID, arg.UserID)
+	return err
+}
+
+const setFeedRetryAfter = `-- name: SetFeedRetryAfter :exec
+UPDATE feeds
+SET
+  retry_after = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+`
+
+type SetFeedRetryAfterParams struct {
+	ID         uuid.UUID
+	RetryAfter sql.NullTime
+}
+
+func (q *Queries) SetFeedRetryAfter(ctx context.Context, arg SetFeedRetryAfterParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedRetryAfter, arg.ID, arg.RetryAfter)
+	return err
+}
+
+const setFeedURL = `-- name: SetFeedURL :one
+UPDATE feeds
+SET
+  url = $2,
+  dead_at = NULL,
+  retry_after = NULL,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, dead_at, retry_after, fetch_full_text
+`
+
+type SetFeedURLParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) SetFeedURL(ctx context.Context, arg SetFeedURLParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedURL, arg.ID, arg.Url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.DeadAt,
+		&i.RetryAfter,
+		&i.FetchFullText,
 	)
 	return i, err
 }
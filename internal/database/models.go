@@ -19,30 +19,126 @@ type Feed struct {
 	Url           string
 	UserID        uuid.UUID
 	LastFetchedAt sql.NullTime
+	DeadAt        sql.NullTime
+	RetryAfter    sql.NullTime
+	FetchFullText bool
+}
+
+type FeedFetch struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	FeedID     uuid.UUID
+	Url        string
+	StatusCode sql.NullInt32
+	Error      sql.NullString
 }
 
 type FeedFollow struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uuid.UUID
+	FeedID       uuid.UUID
+	KeepEpisodes sql.NullInt32
+	Tags         []string
+	Title        sql.NullString
+}
+
+type Filter struct {
+	ID            uuid.UUID
+	Seq           int64
+	CreatedAt     time.Time
+	UserID        uuid.UUID
+	FeedID        uuid.NullUUID
+	Action        string
+	Field         string
+	Pattern       string
+	IsRegex       bool
+	CaseSensitive bool
+}
+
+type Post struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	Seq             int64
+	Content         sql.NullString
+	Author          sql.NullString
+	CommentsUrl     sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	ImageUrl        sql.NullString
+	FullContent     sql.NullString
+}
+
+type PostCategory struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	PostID    uuid.UUID
+	Name      string
+}
+
+type PostEnclosure struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	PostID    uuid.UUID
+	Url       string
+	Length    sql.NullInt64
+	MimeType  sql.NullString
+}
+
+type PostState struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.UUID
-	FeedID    uuid.UUID
+	PostID    uuid.UUID
+	IsRead    bool
+	IsStarred bool
 }
 
-type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+type PostTag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Name      string
 }
 
-type User struct {
+type Rule struct {
+	ID            uuid.UUID
+	Seq           int64
+	CreatedAt     time.Time
+	UserID        uuid.UUID
+	FeedID        uuid.NullUUID
+	Field         string
+	Pattern       string
+	IsRegex       bool
+	CaseSensitive bool
+	Action        string
+	Tag           sql.NullString
+}
+
+type Session struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Name      string
+	TokenHash string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+type User struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	ApiKeyHash     sql.NullString
+	HashedPassword sql.NullString
+	Role           string
 }
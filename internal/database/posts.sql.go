@@ -11,10 +11,23 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countPostsForFeed = `-- name: CountPostsForFeed :one
+SELECT COUNT(*) FROM posts
+WHERE feed_id = $1
+`
+
+func (q *Queries) CountPostsForFeed(ctx context.Context, feedID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPostsForFeed, feedID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, title, url, published_at, description, feed_id, created_at, updated_at)
+INSERT INTO posts (id, title, url, published_at, description, feed_id, content, author, comments_url, duration_seconds, episode, image_url, created_at, updated_at)
 VALUES (
   gen_random_uuid(),
   $1,
@@ -22,18 +35,30 @@ VALUES (
   $3,
   $4,
   $5,
+  $6,
+  $7,
+  $8,
+  $9,
+  $10,
+  $11,
   NOW() AT TIME ZONE 'UTC',
   NOW() AT TIME ZONE 'UTC'
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, seq, content, author, comments_url, duration_seconds, episode, image_url, full_content
 `
 
 type CreatePostParams struct {
-	Title       string
-	Url         string
-	PublishedAt sql.NullTime
-	Description sql.NullString
-	FeedID      uuid.UUID
+	Title           string
+	Url             string
+	PublishedAt     sql.NullTime
+	Description     sql.NullString
+	FeedID          uuid.UUID
+	Content         sql.NullString
+	Author          sql.NullString
+	CommentsUrl     sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	ImageUrl        sql.NullString
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -43,7 +68,71 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.PublishedAt,
 		arg.Description,
 		arg.FeedID,
+		arg.Content,
+		arg.Author,
+		arg.CommentsUrl,
+		arg.DurationSeconds,
+		arg.Episode,
+		arg.ImageUrl,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Seq,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+		&i.DurationSeconds,
+		&i.Episode,
+		&i.ImageUrl,
+		&i.FullContent,
+	)
+	return i, err
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, seq, content, author, comments_url, duration_seconds, episode, image_url, full_content FROM posts
+WHERE id = $1
+`
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Seq,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+		&i.DurationSeconds,
+		&i.Episode,
+		&i.ImageUrl,
+		&i.FullContent,
 	)
+	return i, err
+}
+
+const getPostBySeq = `-- name: GetPostBySeq :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, seq, content, author, comments_url, duration_seconds, episode, image_url, full_content FROM posts
+WHERE seq = $1
+`
+
+func (q *Queries) GetPostBySeq(ctx context.Context, seq int64) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostBySeq, seq)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -54,12 +143,20 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Seq,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+		&i.DurationSeconds,
+		&i.Episode,
+		&i.ImageUrl,
+		&i.FullContent,
 	)
 	return i, err
 }
 
 const getPostByURL = `-- name: GetPostByURL :one
-SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, seq, content, author, comments_url, duration_seconds, episode, image_url, full_content FROM posts
 WHERE url = $1
 `
 
@@ -75,38 +172,155 @@ func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Seq,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+		&i.DurationSeconds,
+		&i.Episode,
+		&i.ImageUrl,
+		&i.FullContent,
 	)
 	return i, err
 }
 
+const getPostIDsBySeqs = `-- name: GetPostIDsBySeqs :many
+SELECT posts.id FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1 AND posts.seq = ANY($2::bigint[])
+`
+
+type GetPostIDsBySeqsParams struct {
+	UserID uuid.UUID
+	Seqs   []int64
+}
+
+func (q *Queries) GetPostIDsBySeqs(ctx context.Context, arg GetPostIDsBySeqsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPostIDsBySeqs, arg.UserID, pq.Array(arg.Seqs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name  FROM posts
+SELECT
+  posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.seq, posts.content, posts.author, posts.comments_url, posts.duration_seconds, posts.episode, posts.image_url, posts.full_content,
+  COALESCE(feed_follows.title, feeds.name) AS feed_name,
+  feeds.url AS feed_url,
+  ARRAY(
+    SELECT post_categories.name FROM post_categories
+    WHERE post_categories.post_id = posts.id
+    ORDER BY post_categories.name
+  )::text[] AS categories,
+  ARRAY(
+    SELECT post_tags.name FROM post_tags
+    WHERE post_tags.post_id = posts.id AND post_tags.user_id = $1
+    ORDER BY post_tags.name
+  )::text[] AS tags
+FROM posts
 INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
 INNER JOIN feeds ON posts.feed_id = feeds.id
 WHERE feed_follows.user_id = $1
+  AND ($2::text IS NULL OR posts.author ILIKE '%' || $2 || '%')
+  AND ($3::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_categories
+    WHERE post_categories.post_id = posts.id
+      AND lower(post_categories.name) = lower($3)
+  ))
+  AND ($4::text IS NULL OR EXISTS (
+    SELECT 1 FROM unnest(feed_follows.tags) AS follow_tag
+    WHERE lower(follow_tag) = lower($4)
+  ) OR EXISTS (
+    SELECT 1 FROM post_tags
+    WHERE post_tags.post_id = posts.id
+      AND post_tags.user_id = $1
+      AND lower(post_tags.name) = lower($4)
+  ))
+  AND ($5::boolean OR (
+    NOT EXISTS (
+      SELECT 1 FROM filters
+      WHERE filters.user_id = $1
+        AND filters.action = 'exclude'
+        AND (filters.feed_id IS NULL OR filters.feed_id = posts.feed_id)
+        AND post_matches(posts.id, filters.field, filters.pattern, filters.is_regex, filters.case_sensitive)
+    )
+    AND (
+      NOT EXISTS (
+        SELECT 1 FROM filters
+        WHERE filters.user_id = $1
+          AND filters.action = 'include'
+          AND (filters.feed_id IS NULL OR filters.feed_id = posts.feed_id)
+      )
+      OR EXISTS (
+        SELECT 1 FROM filters
+        WHERE filters.user_id = $1
+          AND filters.action = 'include'
+          AND (filters.feed_id IS NULL OR filters.feed_id = posts.feed_id)
+          AND post_matches(posts.id, filters.field, filters.pattern, filters.is_regex, filters.case_sensitive)
+      )
+    )
+  ))
 ORDER BY published_at DESC NULLS LAST
-LIMIT $2
+LIMIT $6
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID     uuid.UUID
+	Author     sql.NullString
+	Category   sql.NullString
+	Tag        sql.NullString
+	Unfiltered bool
+	Limit      int32
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	Seq             int64
+	Content         sql.NullString
+	Author          sql.NullString
+	CommentsUrl     sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	ImageUrl        sql.NullString
+	FullContent     sql.NullString
+	FeedName        string
+	FeedUrl         string
+	Categories      []string
+	Tags            []string
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Author,
+		arg.Category,
+		arg.Tag,
+		arg.Unfiltered,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +337,18 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Seq,
+			&i.Content,
+			&i.Author,
+			&i.CommentsUrl,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.ImageUrl,
+			&i.FullContent,
 			&i.FeedName,
+			&i.FeedUrl,
+			pq.Array(&i.Categories),
+			pq.Array(&i.Tags),
 		); err != nil {
 			return nil, err
 		}
@@ -137,3 +362,169 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const getReaderItems = `-- name: GetReaderItems :many
+SELECT
+  posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.seq, posts.content, posts.author, posts.comments_url, posts.duration_seconds, posts.episode, posts.image_url, posts.full_content,
+  COALESCE(feed_follows.title, feeds.name) AS feed_name,
+  feeds.url AS feed_url,
+  COALESCE(post_states.is_read, FALSE)::boolean AS is_read,
+  COALESCE(post_states.is_starred, FALSE)::boolean AS is_starred
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON posts.id = post_states.post_id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+  AND ($2::uuid IS NULL OR posts.feed_id = $2)
+  AND ($3::text IS NULL OR $3 = ANY(feed_follows.tags))
+  AND (COALESCE(cardinality($4::bigint[]), 0) = 0 OR posts.seq = ANY($4::bigint[]))
+  AND (NOT $5::boolean OR NOT COALESCE(post_states.is_read, FALSE))
+  AND (NOT $6::boolean OR COALESCE(post_states.is_read, FALSE))
+  AND (NOT $7::boolean OR COALESCE(post_states.is_starred, FALSE))
+  AND ($8::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < $8)
+  AND ($9::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) > $9)
+ORDER BY
+  CASE WHEN $10::boolean THEN COALESCE(posts.published_at, posts.created_at) END ASC,
+  COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT $11 OFFSET $12
+`
+
+type GetReaderItemsParams struct {
+	UserID      uuid.UUID
+	FeedID      uuid.NullUUID
+	Label       sql.NullString
+	Seqs        []int64
+	ExcludeRead bool
+	OnlyRead    bool
+	OnlyStarred bool
+	OlderThan   sql.NullTime
+	NewerThan   sql.NullTime
+	OldestFirst bool
+	RowLimit    int32
+	RowOffset   int32
+}
+
+type GetReaderItemsRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     sql.NullString
+	PublishedAt     sql.NullTime
+	FeedID          uuid.UUID
+	Seq             int64
+	Content         sql.NullString
+	Author          sql.NullString
+	CommentsUrl     sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	ImageUrl        sql.NullString
+	FullContent     sql.NullString
+	FeedName        string
+	FeedUrl         string
+	IsRead          bool
+	IsStarred       bool
+}
+
+func (q *Queries) GetReaderItems(ctx context.Context, arg GetReaderItemsParams) ([]GetReaderItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReaderItems,
+		arg.UserID,
+		arg.FeedID,
+		arg.Label,
+		pq.Array(arg.Seqs),
+		arg.ExcludeRead,
+		arg.OnlyRead,
+		arg.OnlyStarred,
+		arg.OlderThan,
+		arg.NewerThan,
+		arg.OldestFirst,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReaderItemsRow
+	for rows.Next() {
+		var i GetReaderItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Seq,
+			&i.Content,
+			&i.Author,
+			&i.CommentsUrl,
+			&i.DurationSeconds,
+			&i.Episode,
+			&i.ImageUrl,
+			&i.FullContent,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.IsRead,
+			&i.IsStarred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const movePosts = `-- name: MovePosts :exec
+UPDATE posts
+SET
+  feed_id = $1,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE feed_id = $2
+`
+
+type MovePostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MovePosts(ctx context.Context, arg MovePostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const resetPosts = `-- name: ResetPosts :exec
+DELETE FROM posts
+`
+
+func (q *Queries) ResetPosts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetPosts)
+	return err
+}
+
+const setPostFullContent = `-- name: SetPostFullContent :exec
+UPDATE posts
+SET
+  full_content = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+`
+
+type SetPostFullContentParams struct {
+	ID          uuid.UUID
+	FullContent sql.NullString
+}
+
+func (q *Queries) SetPostFullContent(ctx context.Context, arg SetPostFullContentParams) error {
+	_, err := q.db.ExecContext(ctx, setPostFullContent, arg.ID, arg.FullContent)
+	return err
+}
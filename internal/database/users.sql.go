@@ -7,33 +7,88 @@ package database
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
 )
 
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*) FROM users
+WHERE role = 'admin'
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, name, created_at, updated_at)
+INSERT INTO users (id, name, hashed_password, role, created_at, updated_at)
 VALUES (
   gen_random_uuid(),
   $1,
+  $2,
+  CASE WHEN EXISTS (SELECT 1 FROM users) THEN 'member' ELSE 'admin' END,
   NOW() AT TIME ZONE 'UTC',
   NOW() AT TIME ZONE 'UTC'
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, api_key_hash, hashed_password, role
 `
 
-func (q *Queries) CreateUser(ctx context.Context, name string) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, name)
+type CreateUserParams struct {
+	Name           string
+	HashedPassword sql.NullString
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser, arg.Name, arg.HashedPassword)
 	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.ApiKeyHash,
+		&i.HashedPassword,
+		&i.Role,
+	)
+	return i, err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const getUserByAPIKeyHash = `-- name: GetUserByAPIKeyHash :one
+SELECT id, created_at, updated_at, name, api_key_hash, hashed_password, role FROM users
+WHERE api_key_hash = $1
+`
+
+func (q *Queries) GetUserByAPIKeyHash(ctx context.Context, apiKeyHash sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByAPIKeyHash, apiKeyHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.HashedPassword,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUserByName = `-- name: GetUserByName :one
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, api_key_hash, hashed_password, role FROM users
 WHERE name = $1
 `
 
@@ -45,27 +100,35 @@ func (q *Queries) GetUserByName(ctx context.Context, name string) (User, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.ApiKeyHash,
+		&i.HashedPassword,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :many
-SELECT name FROM users
+SELECT name, role FROM users
 `
 
-func (q *Queries) GetUsers(ctx context.Context) ([]string, error) {
+type GetUsersRow struct {
+	Name string
+	Role string
+}
+
+func (q *Queries) GetUsers(ctx context.Context) ([]GetUsersRow, error) {
 	rows, err := q.db.QueryContext(ctx, getUsers)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []GetUsersRow
 	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
+		var i GetUsersRow
+		if err := rows.Scan(&i.Name, &i.Role); err != nil {
 			return nil, err
 		}
-		items = append(items, name)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -76,6 +139,35 @@ func (q *Queries) GetUsers(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
+const renameUser = `-- name: RenameUser :one
+UPDATE users
+SET
+  name = $1,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE name = $2
+RETURNING id, created_at, updated_at, name, api_key_hash, hashed_password, role
+`
+
+type RenameUserParams struct {
+	NewName string
+	OldName string
+}
+
+func (q *Queries) RenameUser(ctx context.Context, arg RenameUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, renameUser, arg.NewName, arg.OldName)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.HashedPassword,
+		&i.Role,
+	)
+	return i, err
+}
+
 const resetUsers = `-- name: ResetUsers :exec
 DELETE FROM users
 `
@@ -84,3 +176,68 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetUsers)
 	return err
 }
+
+const setUserAPIKeyHash = `-- name: SetUserAPIKeyHash :exec
+UPDATE users
+SET
+  api_key_hash = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+`
+
+type SetUserAPIKeyHashParams struct {
+	ID         uuid.UUID
+	ApiKeyHash sql.NullString
+}
+
+func (q *Queries) SetUserAPIKeyHash(ctx context.Context, arg SetUserAPIKeyHashParams) error {
+	_, err := q.db.ExecContext(ctx, setUserAPIKeyHash, arg.ID, arg.ApiKeyHash)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users
+SET
+  hashed_password = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword sql.NullString
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.ID, arg.HashedPassword)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET
+  role = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE name = $1
+RETURNING id, created_at, updated_at, name, api_key_hash, hashed_password, role
+`
+
+type SetUserRoleParams struct {
+	Name string
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.Name, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.HashedPassword,
+		&i.Role,
+	)
+	return i, err
+}
@@ -1,22 +1,29 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
-	"context"
-	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/thomas-reed/gator/internal/config"
 	"github.com/thomas-reed/gator/internal/database"
 )
 
 type state struct {
-	db *database.Queries
-	cfg *config.Config
+	db   *database.Queries
+	conn *sql.DB
+	cfg  *config.Config
 }
 
 func main() {
@@ -25,6 +32,9 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error reading config: %v", err)
 	}
+	if cfg.MaxFeedBytes > 0 {
+		maxFeedBytes = cfg.MaxFeedBytes
+	}
 
 	// set up db connection
 	db, err := sql.Open("postgres", cfg.DbURL)
@@ -35,8 +45,9 @@ func main() {
 
 	// save state for use in commands
 	programState := &state{
-		db: dbQueries,
-		cfg: &cfg,
+		db:   dbQueries,
+		conn: db,
+		cfg:  &cfg,
 	}
 
 	// build command registry
@@ -44,16 +55,61 @@ func main() {
 		registry: make(map[string]func(s *state, cmd command) error),
 	}
 	cmds.register("login", handlerLogin)
+	cmds.register("logout", handlerLogout)
 	cmds.register("register", handlerRegister)
-	cmds.register("reset", handlerReset)
+	cmds.register("passwd", loggedIn(handlerPasswd))
+	cmds.register("reset", loggedIn(adminOnly(handlerReset)))
+	cmds.register("role", loggedIn(adminOnly(handlerRole)))
 	cmds.register("users", handlerListUsers)
+	cmds.register("whoami", loggedIn(handlerWhoami))
+	cmds.register("user", subcommands(map[string]func(*state, command) error{
+		"rename": loggedIn(handlerRenameUser),
+		"delete": loggedIn(adminOnly(handlerDeleteUser)),
+	}))
 	cmds.register("agg", handlerAggregate)
 	cmds.register("addfeed", loggedIn(handlerAddFeed))
 	cmds.register("feeds", handlerListFeeds)
+	cmds.register("feed", subcommands(map[string]func(*state, command) error{
+		"rename":   loggedIn(handlerRenameFeed),
+		"set-url":  loggedIn(handlerSetFeedURL),
+		"delete":   loggedIn(handlerDeleteFeed),
+		"history":  handlerFeedHistory,
+		"fulltext": loggedIn(handlerFeedFullText),
+	}))
+	cmds.register("read", loggedIn(handlerRead))
+	cmds.register("open", loggedIn(handlerOpen))
+	cmds.register("download", loggedIn(handlerDownload))
+	cmds.register("podcast", subcommands(map[string]func(*state, command) error{
+		"sync": loggedIn(handlerPodcastSync),
+	}))
 	cmds.register("follow", loggedIn(handlerFollow))
 	cmds.register("following", loggedIn(handlerFollowing))
+	cmds.register("tag", subcommands(map[string]func(*state, command) error{
+		"add": loggedIn(handlerTagAdd),
+		"rm":  loggedIn(handlerTagRemove),
+	}))
+	cmds.register("opml", subcommands(map[string]func(*state, command) error{
+		"export": loggedIn(handlerOPMLExport),
+		"import": loggedIn(handlerOPMLImport),
+	}))
 	cmds.register("unfollow", loggedIn(handlerUnfollow))
+	cmds.register("title", loggedIn(handlerTitle))
 	cmds.register("browse", loggedIn(handlerBrowse))
+	cmds.register("tui", loggedIn(handlerTUI))
+	cmds.register("filter", subcommands(map[string]func(*state, command) error{
+		"add":  loggedIn(handlerFilterAdd),
+		"list": loggedIn(handlerFilterList),
+		"rm":   loggedIn(handlerFilterRemove),
+	}))
+	cmds.register("rules", subcommands(map[string]func(*state, command) error{
+		"add":  loggedIn(handlerRulesAdd),
+		"list": loggedIn(handlerRulesList),
+		"rm":   loggedIn(handlerRulesRemove),
+		"test": loggedIn(handlerRulesTest),
+	}))
+	cmds.register("apikey", loggedIn(handlerAPIKey))
+	cmds.register("serve", handlerServe)
+	cmds.register("publish", handlerPublish)
 
 	// parse cmd line arguments
 	if len(os.Args) < 2 {
@@ -71,7 +127,13 @@ func main() {
 
 func loggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func(s *state, cmd command) error {
-		user, err := s.db.GetUserByName(context.Background(), s.cfg.CurrentUsername)
+		if s.cfg.SessionToken == "" {
+			return errors.New("Not logged in.  Usage: gator login <name>")
+		}
+		user, err := s.db.GetUserBySessionToken(context.Background(), hashToken(s.cfg.SessionToken))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("Session expired or invalid - log in again.  Usage: gator login <name>")
+		}
 		if err != nil {
 			return fmt.Errorf("Error getting user info from db:\n%w", err)
 		}
@@ -79,19 +141,139 @@ func loggedIn(handler func(s *state, cmd command, user database.User) error) fun
 	}
 }
 
+func adminOnly(handler func(s *state, cmd command, user database.User) error) func(*state, command, database.User) error {
+	return func(s *state, cmd command, user database.User) error {
+		if user.Role != roleAdmin {
+			return fmt.Errorf("%s requires an admin account", cmd.name)
+		}
+		return handler(s, cmd, user)
+	}
+}
+
+// generateToken returns a random hex token for sessions and API keys.
+func generateToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+// hashToken is what gets stored in the db in place of sessions tokens and
+// API keys, so a leaked db doesn't leak working credentials.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// isUniqueViolation reports whether err is postgres rejecting a duplicate
+// value for a UNIQUE constraint.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 func scrapeFeeds(s *state) error {
 	feed, err := s.db.GetNextFeedToFetch(context.Background())
 	if err != nil {
 		return fmt.Errorf("Error getting next feed to fetch:\n%w", err)
 	}
-	rssFeed, err := fetchFeed(context.Background(), feed.Url)
-	if err != nil {
-		return fmt.Errorf("Error fetching feed:\n%w", err)
-	}
+	// mark it fetched whatever happens, so a broken feed doesn't stay at
+	// the front of the queue
 	if _, err = s.db.MarkFeedFetched(context.Background(), feed.ID); err != nil {
 		return fmt.Errorf("Error marking feed as fetched:\n%w", err)
 	}
-	fmt.Printf("Latests Posts from %s:\n", feed.Name)
+
+	res, body, fetchErr := openURL(context.Background(), feed.Url)
+	var rssFeed *RSSFeed
+	if fetchErr == nil {
+		rssFeed, fetchErr = parseFeed(body, res.ContentType)
+		body.Close()
+	}
+	if err := recordFetch(s, feed, res, fetchErr); err != nil {
+		return err
+	}
+
+	var statusErr *statusError
+	if errors.As(fetchErr, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusGone:
+			if err := s.db.MarkFeedDead(context.Background(), feed.ID); err != nil {
+				return fmt.Errorf("Error marking feed as dead:\n%w", err)
+			}
+			fmt.Printf("Feed %s is gone - it won't be fetched anymore.  Use gator feed set-url to revive it\n", feed.Url)
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			if !res.RetryAfter.IsZero() {
+				if err := s.db.SetFeedRetryAfter(context.Background(), database.SetFeedRetryAfterParams{
+					ID: feed.ID,
+					RetryAfter: sql.NullTime{
+						Time:  res.RetryAfter.UTC(),
+						Valid: true,
+					},
+				}); err != nil {
+					return fmt.Errorf("Error saving retry time:\n%w", err)
+				}
+				fmt.Printf("Feed %s asked to retry after %s\n", feed.Url, res.RetryAfter.Local().Format(time.DateTime))
+			}
+		}
+	}
+	if fetchErr != nil {
+		return fmt.Errorf("Error fetching feed:\n%w", fetchErr)
+	}
+
+	if res.Moved {
+		if feed, err = moveFeed(s, feed, res.URL); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Latests Posts from %s:\n", stripControl(feed.Name))
+	if _, err := savePosts(s, feed, rssFeed); err != nil {
+		return err
+	}
+	return nil
+}
+
+// feedFetchHistory is how many fetches are kept per feed.
+const feedFetchHistory = 100
+
+// recordFetch adds a fetch attempt to the feed's history.  res may be nil if
+// the request didn't get a response.
+func recordFetch(s *state, feed database.Feed, res *fetchResult, fetchErr error) error {
+	params := database.CreateFeedFetchParams{
+		FeedID: feed.ID,
+		Url:    feed.Url,
+	}
+	if res != nil {
+		params.Url = res.URL
+		params.StatusCode = sql.NullInt32{
+			Int32: int32(res.StatusCode),
+			Valid: true,
+		}
+	}
+	if fetchErr != nil {
+		params.Error = sql.NullString{
+			String: fetchErr.Error(),
+			Valid:  true,
+		}
+	}
+	if err := s.db.CreateFeedFetch(context.Background(), params); err != nil {
+		return fmt.Errorf("Error recording feed fetch:\n%w", err)
+	}
+	// only the latest fetches are useful for troubleshooting, and agg adds
+	// one per feed every poll
+	if err := s.db.PruneFeedFetches(context.Background(), database.PruneFeedFetchesParams{
+		FeedID: feed.ID,
+		Keep:   feedFetchHistory,
+	}); err != nil {
+		return fmt.Errorf("Error pruning feed fetch history:\n%w", err)
+	}
+	return nil
+}
+
+// savePosts stores the items of rssFeed that aren't in the db yet, returning
+// how many were added.
+func savePosts(s *state, feed database.Feed, rssFeed *RSSFeed) (int, error) {
+	saved := 0
 	for _, item := range rssFeed.Channel.Item {
 		_, err := s.db.GetPostByURL(context.Background(), item.Link)
 		if err == nil {
@@ -100,45 +282,103 @@ func scrapeFeeds(s *state) error {
 		}
 		parsedTime, err := parseTime(item.PubDate)
 		if err != nil {
-			fmt.Println("Published At time couldn't be parsed - value set to NULL")
+			// better than NULL, which sinks the post to the bottom of browse
+			fmt.Println("Published At time couldn't be parsed - using the time the post was first seen")
+			parsedTime = time.Now().UTC()
 		}
 		pubTime := sql.NullTime{
 			Time: parsedTime,
-			Valid: err == nil,
+			Valid: true,
+		}
+		// strip anything that could run or track in whatever renders these
+		// later, before the image is picked from them
+		base := resolveURL(feed.Url, rssFeed.Channel.Link)
+		if item.Link != "" {
+			base = resolveURL(base, item.Link)
 		}
+		item.Description = sanitizeHTML(item.Description, base)
+		item.Content = sanitizeHTML(item.Content, base)
 		desc := sql.NullString{
 			String: item.Description,
 			Valid: item.Description != "",
 		}
+		image := item.imageURL()
+		if image == "" {
+			// podcast episodes usually just use the show's artwork
+			image = rssFeed.Channel.ItunesImage.Href
+		}
 		post, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
-			Title: item.Title,
-			Url: item.Link,
-			PublishedAt: pubTime,
-			Description: desc,
-			FeedID: feed.ID,
+			Title:           item.Title,
+			Url:             item.Link,
+			PublishedAt:     pubTime,
+			Description:     desc,
+			FeedID:          feed.ID,
+			Content:         nullString(item.Content),
+			Author:          nullString(item.authorName()),
+			CommentsUrl:     nullString(item.Comments),
+			DurationSeconds: nullInt32(item.durationSeconds()),
+			Episode:         nullInt32(item.episodeNumber()),
+			ImageUrl:        nullString(image),
 		})
 		if err != nil {
-			return fmt.Errorf("Error adding post to db:\n%w", err)
+			return saved, fmt.Errorf("Error adding post to db:\n%w", err)
+		}
+		if feed.FetchFullText {
+			// the post is still worth keeping with just its description
+			if _, err := saveFullText(s, post); err != nil {
+				fmt.Printf("Couldn't get the full text of '%s': %v\n", stripControl(post.Title), err)
+			}
+		}
+		for _, category := range item.Categories {
+			if category == "" {
+				continue
+			}
+			if err := s.db.CreatePostCategory(context.Background(), database.CreatePostCategoryParams{
+				PostID: post.ID,
+				Name:   category,
+			}); err != nil {
+				return saved, fmt.Errorf("Error adding post category to db:\n%w", err)
+			}
+		}
+		for _, enclosure := range item.Enclosures {
+			if strings.TrimSpace(enclosure.URL) == "" {
+				continue
+			}
+			length, err := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64)
+			if err := s.db.CreatePostEnclosure(context.Background(), database.CreatePostEnclosureParams{
+				PostID: post.ID,
+				Url:    strings.TrimSpace(enclosure.URL),
+				Length: sql.NullInt64{
+					Int64: length,
+					Valid: err == nil && length > 0,
+				},
+				MimeType: nullString(enclosure.Type),
+			}); err != nil {
+				return saved, fmt.Errorf("Error adding post enclosure to db:\n%w", err)
+			}
+		}
+		saved++
+		fmt.Printf("Post downloaded: %s\n", stripControl(post.Title))
+		// after the categories are stored, so rules can match them.  A rule
+		// that fails shouldn't stop the rest of the feed being saved
+		if err := applyRules(s, feed, post); err != nil {
+			fmt.Printf("Couldn't apply rules to '%s': %v\n", stripControl(post.Title), err)
 		}
-		fmt.Printf("Post downloaded: %s\n", post.Title)
 	}
-	return nil
+	return saved, nil
 }
 
-func parseTime(timeStr string) (time.Time, error) {
-    formats := []string{
-        time.RFC1123Z,
-        time.RFC1123,
-        time.RFC3339,
-        // add more if needed
-    }
-    
-    for _, format := range formats {
-        t, err := time.Parse(format, timeStr)
-        if err == nil {
-            return t, nil
-        }
-    }
-    
-    return time.Time{}, fmt.Errorf("could not parse time: %s", timeStr)
+func nullString(str string) sql.NullString {
+	str = strings.TrimSpace(str)
+	return sql.NullString{
+		String: str,
+		Valid:  str != "",
+	}
+}
+
+func nullInt32(value int, ok bool) sql.NullInt32 {
+	return sql.NullInt32{
+		Int32: int32(value),
+		Valid: ok,
+	}
 }
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thomas-reed/gator/internal/database"
+	"golang.org/x/net/html/charset"
+)
+
+// OPML is how feed readers import and export subscription lists.  Tags are
+// written both as folders (outlines wrapping the feeds) and in each feed's
+// category attribute, since readers differ in which of the two they use.
+
+type opmlDocument struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"head>title"`
+	Created string        `xml:"head>dateCreated,omitempty"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+func handlerOPMLExport(s *state, cmd command, user database.User) error {
+	_, flags, err := parseFlags(cmd.args)
+	if err != nil {
+		return fmt.Errorf("%w.  Usage: gator %s [--output <file>]", err, cmd.name)
+	}
+	follows, err := s.db.GetFeedFollowsByUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting user follows:\n%w", err)
+	}
+	slices.SortFunc(follows, func(a, b database.GetFeedFollowsByUserRow) int {
+		return strings.Compare(strings.ToLower(a.FeedName), strings.ToLower(b.FeedName))
+	})
+
+	doc := opmlDocument{
+		Version: "2.0",
+		Title:   fmt.Sprintf("gator subscriptions for %s", user.Name),
+		Created: time.Now().UTC().Format(time.RFC1123Z),
+	}
+	folders := map[string]int{}
+	for _, follow := range follows {
+		categories := []string{}
+		for _, tag := range follow.Tags {
+			categories = append(categories, "/"+tag)
+		}
+		outline := opmlOutline{
+			Text:     follow.FeedName,
+			Title:    follow.FeedName,
+			Type:     "rss",
+			XMLURL:   follow.FeedUrl,
+			Category: strings.Join(categories, ","),
+		}
+		if len(follow.Tags) == 0 {
+			doc.Body = append(doc.Body, outline)
+			continue
+		}
+		for _, tag := range follow.Tags {
+			i, ok := folders[tag]
+			if !ok {
+				i = len(doc.Body)
+				folders[tag] = i
+				doc.Body = append(doc.Body, opmlOutline{Text: tag, Title: tag})
+			}
+			doc.Body[i].Outlines = append(doc.Body[i].Outlines, outline)
+		}
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error writing OPML:\n%w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	data = append(data, '\n')
+	if flags["output"] == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(flags["output"], data, 0644); err != nil {
+		return fmt.Errorf("Error writing OPML:\n%w", err)
+	}
+	fmt.Printf("Wrote %d feeds for %s to %s\n", len(follows), user.Name, flags["output"])
+	return nil
+}
+
+func handlerOPMLImport(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf("File required.  Usage: gator %s <file>", cmd.name)
+	}
+	file, err := os.Open(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("Error opening %s:\n%w", cmd.args[0], err)
+	}
+	defer file.Close()
+	var doc opmlDocument
+	decoder := xml.NewDecoder(file)
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(&doc); err != nil {
+		return fmt.Errorf("Error parsing %s:\n%w", cmd.args[0], err)
+	}
+
+	imported, failed := 0, 0
+	var walk func(outlines []opmlOutline, folders []string)
+	walk = func(outlines []opmlOutline, folders []string) {
+		for _, outline := range outlines {
+			title := strings.TrimSpace(outline.Title)
+			if title == "" {
+				title = strings.TrimSpace(outline.Text)
+			}
+			if outline.XMLURL == "" {
+				// an outline without a feed is a folder
+				walk(outline.Outlines, append(slices.Clone(folders), title))
+				continue
+			}
+			if err := importOutline(s, user, outline.XMLURL, title, opmlTags(folders, outline.Category)); err != nil {
+				fmt.Printf("Couldn't import %s: %v\n", outline.XMLURL, err)
+				failed++
+				continue
+			}
+			imported++
+		}
+	}
+	walk(doc.Body, nil)
+
+	fmt.Printf("Imported %d feeds", imported)
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+	if imported > 0 {
+		fmt.Println("Feeds new to gator are fetched the next time agg runs")
+	}
+	return nil
+}
+
+// importOutline follows one feed from an OPML file and adds tags to the
+// user's tags for it.
+func importOutline(s *state, user database.User, feedURL, title string, tags []string) error {
+	feed, err := subscribeFeed(context.Background(), s, user, feedURL, title)
+	if err != nil {
+		return err
+	}
+	follow, err := s.db.GetFeedFollow(context.Background(), database.GetFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("Error getting feed follow from db:\n%w", err)
+	}
+	merged := follow.Tags
+	for _, tag := range tags {
+		merged = addTag(merged, tag)
+	}
+	if len(merged) != len(follow.Tags) {
+		if err := s.db.SetFeedFollowTags(context.Background(), database.SetFeedFollowTagsParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+			Tags:   merged,
+		}); err != nil {
+			return fmt.Errorf("Error saving tags:\n%w", err)
+		}
+	}
+	// a different name in the file is kept as the user's own title for the
+	// feed, unless they've already picked one
+	name := feed.Name
+	if follow.Title.Valid {
+		name = follow.Title.String
+	} else if title != "" && title != feed.Name {
+		if err := s.db.SetFeedFollowTitle(context.Background(), database.SetFeedFollowTitleParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+			Title:  nullString(title),
+		}); err != nil {
+			return fmt.Errorf("Error saving title:\n%w", err)
+		}
+		name = title
+	}
+	fmt.Printf("Following '%s'", stripControl(name))
+	if len(merged) > 0 {
+		fmt.Printf(" (%s)", strings.Join(merged, ", "))
+	}
+	fmt.Println()
+	return nil
+}
+
+// opmlTags turns the folders an outline is in and its category attribute
+// (comma separated paths like "/tech/golang") into tags.
+func opmlTags(folders []string, category string) []string {
+	names := append([]string{}, folders...)
+	for _, path := range strings.Split(category, ",") {
+		names = append(names, strings.Split(path, "/")...)
+	}
+	tags := []string{}
+	for _, name := range names {
+		if tag, err := normalizeTag(name); err == nil {
+			tags = addTag(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/thomas-reed/gator/internal/database"
+)
+
+func handlerDownload(s *state, cmd command, user database.User) error {
+	args, flags, err := parseFlags(cmd.args)
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("Post required.  Usage: gator %s <post_id|post_url> [--dir <path>]", cmd.name)
+	}
+	dir := flags["dir"]
+	if dir == "" {
+		dir = "."
+	}
+	post, err := resolvePost(s, args[0])
+	if err != nil {
+		return err
+	}
+	enclosures, err := s.db.GetEnclosuresForPost(context.Background(), post.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting enclosures from db:\n%w", err)
+	}
+	if len(enclosures) == 0 {
+		return fmt.Errorf("Post '%s' has no media to download", post.Title)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("Error creating %s:\n%w", dir, err)
+	}
+
+	for _, enclosure := range enclosures {
+		dest := filepath.Join(dir, enclosureFileName(post.Seq, enclosure.Url, enclosure.MimeType.String))
+		if err := downloadEnclosure(context.Background(), enclosure.Url, dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func handlerPodcastSync(s *state, cmd command, user database.User) error {
+	_, flags, err := parseFlags(cmd.args)
+	if err != nil || flags["feed"] == "" || flags["dir"] == "" {
+		return fmt.Errorf("Feed URL and directory required.  Usage: gator %s --feed <feed_url> --dir <path> [--keep <n|all>]", cmd.name)
+	}
+	feed, err := findFeed(context.Background(), s.db, flags["feed"])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("No feed found with URL %s", flags["feed"])
+	}
+	if err != nil {
+		return fmt.Errorf("Error getting feed from db:\n%w", err)
+	}
+	follow, err := s.db.GetFeedFollow(context.Background(), database.GetFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("You don't follow '%s'.  Usage: gator follow %s", feed.Name, feed.Url)
+	}
+	if err != nil {
+		return fmt.Errorf("Error getting feed follow from db:\n%w", err)
+	}
+
+	// --keep is remembered for the next sync
+	if value, ok := flags["keep"]; ok {
+		keep := sql.NullInt32{}
+		if value != "all" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("Invalid --keep value %s - use a number of episodes or 'all'", value)
+			}
+			keep = sql.NullInt32{Int32: int32(n), Valid: true}
+		}
+		if err := s.db.SetFeedFollowKeepEpisodes(context.Background(), database.SetFeedFollowKeepEpisodesParams{
+			UserID:       user.ID,
+			FeedID:       feed.ID,
+			KeepEpisodes: keep,
+		}); err != nil {
+			return fmt.Errorf("Error saving keep setting:\n%w", err)
+		}
+		follow.KeepEpisodes = keep
+	}
+
+	enclosures, err := s.db.GetEnclosuresForFeed(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting enclosures from db:\n%w", err)
+	}
+	if err := os.MkdirAll(flags["dir"], 0755); err != nil {
+		return fmt.Errorf("Error creating %s:\n%w", flags["dir"], err)
+	}
+
+	// newest first, one file per episode
+	episodes := []database.GetEnclosuresForFeedRow{}
+	seen := map[uuid.UUID]bool{}
+	for _, enclosure := range enclosures {
+		if !seen[enclosure.PostID] {
+			seen[enclosure.PostID] = true
+			episodes = append(episodes, enclosure)
+		}
+	}
+	if follow.KeepEpisodes.Valid {
+		fmt.Printf("Syncing the latest %d of %d episodes of '%s' to %s\n", min(int(follow.KeepEpisodes.Int32), len(episodes)), len(episodes), feed.Name, flags["dir"])
+	} else {
+		fmt.Printf("Syncing %d episodes of '%s' to %s\n", len(episodes), feed.Name, flags["dir"])
+	}
+
+	failed := 0
+	for i, episode := range episodes {
+		dest := filepath.Join(flags["dir"], enclosureFileName(episode.PostSeq, episode.Url, episode.MimeType.String))
+		if follow.KeepEpisodes.Valid && i >= int(follow.KeepEpisodes.Int32) {
+			for _, file := range []string{dest, dest + partSuffix} {
+				if err := os.Remove(file); err == nil {
+					fmt.Printf("Removed old episode %s\n", file)
+				} else if !errors.Is(err, os.ErrNotExist) {
+					return fmt.Errorf("Error removing %s:\n%w", file, err)
+				}
+			}
+			continue
+		}
+		if err := downloadEnclosure(context.Background(), episode.Url, dest); err != nil {
+			// carry on with the other episodes, a rerun resumes this one
+			fmt.Printf("Couldn't download '%s': %v\n", stripControl(episode.PostTitle), err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d episodes failed to download - run the sync again to resume them", failed)
+	}
+	return nil
+}
+
+// resolvePost finds a post by the short ID shown in browse, its UUID or its
+// URL.
+func resolvePost(s *state, ref string) (database.Post, error) {
+	var post database.Post
+	var err error
+	if seq, convErr := strconv.ParseInt(ref, 10, 64); convErr == nil {
+		post, err = s.db.GetPostBySeq(context.Background(), seq)
+	} else if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		post, err = s.db.GetPostByID(context.Background(), id)
+	} else {
+		post, err = s.db.GetPostByURL(context.Background(), ref)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Post{}, fmt.Errorf("No post found for %s", ref)
+	}
+	if err != nil {
+		return database.Post{}, fmt.Errorf("Error getting post from db:\n%w", err)
+	}
+	return post, nil
+}
+
+// enclosureFileName names a downloaded file after the post's ID and the
+// file name in the enclosure URL, so the same episode always maps to the
+// same file.
+func enclosureFileName(seq int64, enclosureURL, mimeType string) string {
+	name := ""
+	if u, err := url.Parse(enclosureURL); err == nil {
+		name = path.Base(u.Path)
+	}
+	if name == "." || name == "/" {
+		name = ""
+	}
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" {
+		name = "episode"
+	}
+	if path.Ext(name) == "" && mimeType != "" {
+		if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	return fmt.Sprintf("%d-%s", seq, name)
+}
+
+const partSuffix = ".part"
+
+// downloadEnclosure downloads fileURL to dest, going through dest.part so an
+// interrupted download is resumed with a Range request next time.
+func downloadEnclosure(ctx context.Context, fileURL, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		fmt.Printf("Already downloaded: %s\n", dest)
+		return nil
+	}
+	partPath := dest + partSuffix
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+	if err != nil {
+		return fmt.Errorf("Error creating request to %s:\n%w", fileURL, err)
+	}
+	req.Header.Set("User-Agent", "gator")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	// no timeout - episodes can take a while
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error performing request:\n%w", err)
+	}
+	defer res.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case res.StatusCode == http.StatusPartialContent && strings.HasPrefix(res.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+		fmt.Printf("Resuming %s from %s\n", dest, formatBytes(offset))
+		flags |= os.O_APPEND
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the part file already holds the whole thing
+		return os.Rename(partPath, dest)
+	case res.StatusCode >= 200 && res.StatusCode <= 299 && res.StatusCode != http.StatusPartialContent:
+		// no resume support, start over
+		fmt.Printf("Downloading %s\n", dest)
+		flags |= os.O_TRUNC
+	default:
+		return &statusError{URL: fileURL, StatusCode: res.StatusCode}
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("Error opening %s:\n%w", partPath, err)
+	}
+	written, err := io.Copy(file, res.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Download of %s interrupted after %s - run it again to resume:\n%w", dest, formatBytes(written), err)
+	}
+	if err := os.Rename(partPath, dest); err != nil {
+		return fmt.Errorf("Error saving %s:\n%w", dest, err)
+	}
+	fmt.Printf("Saved %s\n", dest)
+	return nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatDuration(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
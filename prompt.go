@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readLine prompts for a single line of input.  Hitting EOF before any input
+// is treated as an empty answer.
+func readLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readPassword prompts for a password without echoing it when stdin is a
+// terminal, and falls back to reading a plain line so one can be piped in.
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readLine(prompt)
+	}
+	fmt.Print(prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/thomas-reed/gator/internal/database"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+var errNoArticle = errors.New("no article found on the page")
+
+var (
+	// class names and ids of page furniture rather than the article
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|comment|cookie|disqus|footer|header|menu|modal|nav|newsletter|pagination|popup|promo|related|share|sidebar|social|sponsor|subscribe|widget|advert|\bads?\b`)
+	maybeCandidate    = regexp.MustCompile(`(?i)article|body|column|content|entry|main|post|story|text`)
+	positiveWeight    = regexp.MustCompile(`(?i)article|body|content|entry|hentry|main|page|post|story|text|blog`)
+	negativeWeight    = regexp.MustCompile(`(?i)comment|footer|footnote|masthead|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|sponsor|shopping|tags|tool|widget|advert`)
+)
+
+// fetchArticle downloads pageURL and extracts its main article as sanitized
+// HTML.
+func fetchArticle(ctx context.Context, pageURL string) (string, error) {
+	res, body, err := openURL(ctx, pageURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	return extractArticle(body, res.ContentType, res.URL)
+}
+
+// saveFullText fetches a post's article and stores it as the post's full
+// content, returning it.
+func saveFullText(s *state, post database.Post) (string, error) {
+	article, err := fetchArticle(context.Background(), post.Url)
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.SetPostFullContent(context.Background(), database.SetPostFullContentParams{
+		ID:          post.ID,
+		FullContent: nullString(article),
+	}); err != nil {
+		return "", fmt.Errorf("Error saving full text:\n%w", err)
+	}
+	return article, nil
+}
+
+// extractArticle finds the main article in a web page, the way browsers'
+// reader modes do: paragraphs are scored on their length and commas, the
+// scores are added to their parent and grandparent elements, and the element
+// that ends up with the best score (less its share of link text) is taken as
+// the article, along with any siblings that score nearly as well.
+func extractArticle(page io.Reader, contentType, pageURL string) (string, error) {
+	reader, err := charset.NewReader(page, contentType)
+	if err != nil {
+		return "", fmt.Errorf("Error decoding page:\n%w", err)
+	}
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing page:\n%w", err)
+	}
+	removeClutter(doc)
+
+	scores := map[*html.Node]float64{}
+	candidates := []*html.Node{}
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode || node.Data == "html" {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			scores[node] = initialScore(node)
+			candidates = append(candidates, node)
+		}
+		scores[node] += score
+	}
+	for node := range doc.Descendants() {
+		if node.Type != html.ElementNode {
+			continue
+		}
+		switch node.Data {
+		case "p", "pre", "td", "blockquote":
+		default:
+			continue
+		}
+		text := strings.TrimSpace(textOf(node))
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(length/100), 3)
+		addScore(node.Parent, score)
+		if node.Parent != nil {
+			addScore(node.Parent.Parent, score/2)
+		}
+	}
+
+	var top *html.Node
+	for _, candidate := range candidates {
+		scores[candidate] *= 1 - linkDensity(candidate)
+		if top == nil || scores[candidate] > scores[top] {
+			top = candidate
+		}
+	}
+	if top == nil {
+		return "", errNoArticle
+	}
+
+	// pick up the rest of the article when it's split over several siblings
+	threshold := max(10, scores[top]*0.2)
+	var sb strings.Builder
+	parent := top.Parent
+	if parent == nil {
+		parent = top
+	}
+	for sibling := parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+		keep := sibling == top
+		if score, ok := scores[sibling]; ok && score >= threshold {
+			keep = true
+		}
+		if sibling.Data == "p" {
+			text := strings.TrimSpace(textOf(sibling))
+			if utf8.RuneCountInString(text) > 80 && linkDensity(sibling) < 0.25 {
+				keep = true
+			}
+		}
+		if keep {
+			if err := html.Render(&sb, sibling); err != nil {
+				return "", fmt.Errorf("Error rendering article:\n%w", err)
+			}
+		}
+	}
+
+	article := sanitizeHTML(sb.String(), pageURL)
+	if article == "" {
+		return "", errNoArticle
+	}
+	return article, nil
+}
+
+// removeClutter drops elements that are never part of the article.
+func removeClutter(doc *html.Node) {
+	remove := []*html.Node{}
+	for node := range doc.Descendants() {
+		if node.Type == html.CommentNode {
+			remove = append(remove, node)
+			continue
+		}
+		if node.Type != html.ElementNode {
+			continue
+		}
+		switch node.Data {
+		case "script", "style", "noscript", "iframe", "form", "nav", "aside", "footer", "button", "svg", "template":
+			remove = append(remove, node)
+			continue
+		case "html", "body", "article", "main":
+			continue
+		}
+		names := attr(node, "class") + " " + attr(node, "id")
+		if unlikelyCandidate.MatchString(names) && !maybeCandidate.MatchString(names) {
+			remove = append(remove, node)
+		}
+	}
+	for _, node := range remove {
+		// nodes inside one that's already gone are removed from the detached
+		// subtree, which is harmless
+		node.Parent.RemoveChild(node)
+	}
+}
+
+// initialScore favours elements that usually hold articles, going by their
+// tag and class names.
+func initialScore(node *html.Node) float64 {
+	score := 0.0
+	switch node.Data {
+	case "article", "main":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	for _, name := range []string{attr(node, "class"), attr(node, "id")} {
+		if name == "" {
+			continue
+		}
+		if negativeWeight.MatchString(name) {
+			score -= 25
+		}
+		if positiveWeight.MatchString(name) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// linkDensity is the share of node's text that's inside links.
+func linkDensity(node *html.Node) float64 {
+	length := utf8.RuneCountInString(strings.TrimSpace(textOf(node)))
+	if length == 0 {
+		return 0
+	}
+	linkLength := 0
+	for n := range node.Descendants() {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			linkLength += utf8.RuneCountInString(strings.TrimSpace(textOf(n)))
+		}
+	}
+	return min(float64(linkLength)/float64(length), 1)
+}
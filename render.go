@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/term"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+)
+
+// minWrapWidth is the fewest columns text is wrapped to, however deeply
+// it's indented.
+const minWrapWidth = 20
+
+type renderOptions struct {
+	Width int
+	// ANSI turns on bold/italic/underline escape codes
+	ANSI bool
+}
+
+// terminalOptions renders for stdout: its width and ANSI styling if it's a
+// terminal (and NO_COLOR isn't set), 80 columns of plain text otherwise.
+func terminalOptions() renderOptions {
+	opts := renderOptions{Width: 80}
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return opts
+	}
+	if width, _, err := term.GetSize(fd); err == nil && width > 20 {
+		opts.Width = width
+	}
+	opts.ANSI = os.Getenv("NO_COLOR") == ""
+	return opts
+}
+
+// renderHTML turns a post's HTML into wrapped terminal text: block elements
+// become paragraphs, lists get bullets or numbers, and links are collected as
+// numbered footnotes at the end.
+func renderHTML(fragment string, opts renderOptions) string {
+	doc, err := html.Parse(strings.NewReader(fragment))
+	if err != nil {
+		return stripControl(fragment)
+	}
+	// after parsing, so escapes written as character references go too
+	for node := range doc.Descendants() {
+		node.Data = stripControl(node.Data)
+		for i := range node.Attr {
+			node.Attr[i].Val = stripControl(node.Attr[i].Val)
+		}
+	}
+	r := &htmlRenderer{opts: opts}
+	r.walk(doc)
+	r.flush()
+
+	out := strings.TrimRight(r.out.String(), "\n")
+	if len(r.links) > 0 {
+		out += "\n"
+		for i, link := range r.links {
+			out += fmt.Sprintf("\n[%d] %s", i+1, link)
+		}
+	}
+	return out
+}
+
+// stripControl removes control characters from feed text, so escape
+// sequences in a hostile feed can't reach the terminal.  Newlines and tabs
+// are kept for preformatted text.
+func stripControl(text string) string {
+	return strings.Map(func(char rune) rune {
+		if char == '\n' || char == '\t' {
+			return char
+		}
+		if char < 0x20 || (char >= 0x7f && char < 0xa0) {
+			return -1
+		}
+		return char
+	}, text)
+}
+
+// word is a piece of text that's kept together when wrapping, with its
+// styling already applied.
+type word struct {
+	text  string
+	width int
+}
+
+type htmlRenderer struct {
+	opts  renderOptions
+	out   strings.Builder
+	links []string
+
+	// the paragraph being built
+	words []word
+	// glue is set when the last text didn't end in whitespace, so the next
+	// bit of text continues the same word
+	glue bool
+
+	bold, italic, underline int
+	listDepth               int
+
+	// indent is the prefix of every line, bullet the one to use instead on
+	// the next line written (for list items)
+	indent    string
+	bullet    string
+	needBlank bool
+}
+
+func (r *htmlRenderer) walk(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		r.addText(node.Data)
+		return
+	case html.ElementNode:
+	default:
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			r.walk(child)
+		}
+		return
+	}
+
+	switch node.Data {
+	case "script", "style", "head", "noscript", "template", "iframe":
+		return
+	case "br":
+		r.flush()
+		return
+	case "hr":
+		r.block()
+		r.writeLine(strings.Repeat("─", max(min(r.wrapWidth(), 40), 1)))
+		r.needBlank = true
+		return
+	case "img":
+		alt := strings.TrimSpace(attr(node, "alt"))
+		if alt == "" {
+			alt = "image"
+		}
+		r.addText(" [" + alt + "] ")
+		return
+	}
+
+	switch node.Data {
+	case "p", "div", "section", "article", "header", "footer", "figure", "figcaption", "table", "tr", "dl", "dd", "dt":
+		r.block()
+		r.walkChildren(node)
+		r.block()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.block()
+		r.bold++
+		r.walkChildren(node)
+		r.bold--
+		r.block()
+	case "blockquote":
+		r.block()
+		saved := r.indent
+		r.indent = r.nest("> ")
+		r.walkChildren(node)
+		r.block()
+		r.indent = saved
+	case "pre":
+		// the whole block at once, since highlighted code splits lines
+		// across many <span>s
+		r.block()
+		r.writePre(textOf(node))
+		r.block()
+	case "ul", "ol":
+		// nested lists carry on without blank lines
+		nested := r.listDepth > 0
+		if nested {
+			r.flush()
+		} else {
+			r.block()
+		}
+		r.listDepth++
+		number := 1
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode || child.Data != "li" {
+				r.walk(child)
+				continue
+			}
+			marker := "• "
+			if node.Data == "ol" {
+				marker = fmt.Sprintf("%d. ", number)
+				number++
+			}
+			r.flush()
+			saved := r.indent
+			r.bullet = saved + marker
+			r.indent = r.nest(strings.Repeat(" ", utf8.RuneCountInString(marker)))
+			r.walkChildren(child)
+			r.flush()
+			r.indent = saved
+			r.bullet = ""
+			r.needBlank = false
+		}
+		r.listDepth--
+		if nested {
+			r.flush()
+		} else {
+			r.block()
+		}
+	case "b", "strong":
+		r.bold++
+		r.walkChildren(node)
+		r.bold--
+	case "i", "em", "cite":
+		r.italic++
+		r.walkChildren(node)
+		r.italic--
+	case "u":
+		r.underline++
+		r.walkChildren(node)
+		r.underline--
+	case "a":
+		r.underline++
+		r.walkChildren(node)
+		r.underline--
+		href := strings.TrimSpace(attr(node, "href"))
+		if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "javascript:") && href != strings.TrimSpace(textOf(node)) {
+			r.links = append(r.links, href)
+			r.glue = true
+			r.addText(fmt.Sprintf("[%d]", len(r.links)))
+		}
+	default:
+		r.walkChildren(node)
+	}
+}
+
+func (r *htmlRenderer) walkChildren(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		r.walk(child)
+	}
+}
+
+// addText splits text into words, styling them as it goes.
+func (r *htmlRenderer) addText(text string) {
+	if text == "" {
+		return
+	}
+	startsWithSpace := strings.TrimLeft(text, " \t\r\n") != text
+	fields := strings.Fields(text)
+	for i, field := range fields {
+		styled := r.style(field)
+		width := utf8.RuneCountInString(field)
+		if i == 0 && r.glue && !startsWithSpace && len(r.words) > 0 {
+			last := &r.words[len(r.words)-1]
+			last.text += styled
+			last.width += width
+			continue
+		}
+		r.words = append(r.words, word{text: styled, width: width})
+	}
+	if len(fields) > 0 {
+		r.glue = strings.TrimRight(text, " \t\r\n") == text
+	} else {
+		r.glue = false
+	}
+}
+
+func (r *htmlRenderer) style(text string) string {
+	if !r.opts.ANSI || (r.bold == 0 && r.italic == 0 && r.underline == 0) {
+		return text
+	}
+	codes := ""
+	if r.bold > 0 {
+		codes += ansiBold
+	}
+	if r.italic > 0 {
+		codes += ansiItalic
+	}
+	if r.underline > 0 {
+		codes += ansiUnderline
+	}
+	return codes + text + ansiReset
+}
+
+// block ends the current paragraph and asks for a blank line before the next.
+func (r *htmlRenderer) block() {
+	r.flush()
+	if r.out.Len() > 0 {
+		r.needBlank = true
+	}
+}
+
+// flush wraps the current paragraph's words into lines.
+func (r *htmlRenderer) flush() {
+	r.glue = false
+	if len(r.words) == 0 {
+		return
+	}
+	width := r.wrapWidth()
+	line, lineWidth := "", 0
+	for _, w := range r.words {
+		if lineWidth > 0 && lineWidth+1+w.width > width {
+			r.writeLine(line)
+			line, lineWidth = "", 0
+		}
+		if lineWidth > 0 {
+			line += " "
+			lineWidth++
+		}
+		line += w.text
+		lineWidth += w.width
+	}
+	r.writeLine(line)
+	r.words = nil
+}
+
+// wrapWidth is how much of a line is left for text after the indent.
+func (r *htmlRenderer) wrapWidth() int {
+	return max(r.opts.Width-utf8.RuneCountInString(r.indent), minWrapWidth)
+}
+
+// nest returns the indent with extra added, or as it is once deeply nested
+// quotes and lists would leave too little room for text.
+func (r *htmlRenderer) nest(extra string) string {
+	indent := r.indent + extra
+	if r.opts.Width-utf8.RuneCountInString(indent) < minWrapWidth {
+		return r.indent
+	}
+	return indent
+}
+
+func (r *htmlRenderer) writeLine(line string) {
+	if r.needBlank {
+		r.out.WriteString("\n")
+		r.needBlank = false
+	}
+	prefix := r.indent
+	if r.bullet != "" {
+		prefix, r.bullet = r.bullet, ""
+	}
+	r.out.WriteString(prefix + line + "\n")
+}
+
+// writePre writes preformatted text as is, only indenting it.
+func (r *htmlRenderer) writePre(text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		r.writeLine(r.style(line))
+	}
+}
+
+func attr(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textOf(node *html.Node) string {
+	var sb strings.Builder
+	for n := range node.Descendants() {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+	}
+	return sb.String()
+}
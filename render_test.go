@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestRenderHTMLDeeplyNested checks that quotes and lists nested deeper than
+// the width allows don't crash rendering or squeeze the text out.
+func TestRenderHTMLDeeplyNested(t *testing.T) {
+	tests := []struct {
+		name  string
+		open  string
+		close string
+		depth int
+		width int
+	}{
+		{"blockquotes", "<blockquote>", "</blockquote>", 45, 80},
+		{"blockquotes narrow", "<blockquote>", "</blockquote>", 12, 21},
+		{"lists", "<ul><li>", "</li></ul>", 45, 80},
+		{"lists narrow", "<ol><li>", "</li></ol>", 12, 21},
+	}
+	text := strings.Repeat("word ", 30)
+	for _, tt := range tests {
+		fragment := strings.Repeat(tt.open, tt.depth) + "<hr><p>" + text + "</p>" + strings.Repeat(tt.close, tt.depth)
+		out := renderHTML(fragment, renderOptions{Width: tt.width})
+		for _, line := range strings.Split(out, "\n") {
+			if width := utf8.RuneCountInString(line); width > max(tt.width, minWrapWidth)+4 {
+				t.Errorf("%s: line is %d columns at width %d: %q", tt.name, width, tt.width, line)
+			}
+		}
+		if !strings.Contains(out, "─") {
+			t.Errorf("%s: missing rule for <hr>", tt.name)
+		}
+		if strings.Count(out, "word") != 30 {
+			t.Errorf("%s: lost text:\n%s", tt.name, out)
+		}
+	}
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
-	"context"
+	"bufio"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
 	"io"
-	"net/http"
-	"time"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
 )
 
 type RSSFeed struct {
@@ -16,44 +24,437 @@ type RSSFeed struct {
 		Link        string    `xml:"link"`
 		Description string    `xml:"description"`
 		Item        []RSSItem `xml:"item"`
+		ItunesImage rssHref   `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
 	} `xml:"channel"`
 }
 
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Author      string   `xml:"author"`
+	Categories  []string `xml:"category"`
+	Comments    string   `xml:"comments"`
+
+	// podcasts
+	Enclosures  []RSSEnclosure `xml:"enclosure"`
+	Duration    string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Episode     string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ItunesImage rssHref        `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+
+	// Media RSS, used by YouTube, photo blogs and many news sites
+	MediaContents   []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaGroups     []mediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssHref struct {
+	Href string `xml:"href,attr"`
+}
+
+type mediaContent struct {
+	URL        string           `xml:"url,attr"`
+	Type       string           `xml:"type,attr"`
+	Medium     string           `xml:"medium,attr"`
+	Thumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type mediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+type mediaGroup struct {
+	Contents   []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+// imageURL picks the item's picture: a Media RSS thumbnail or image, the
+// episode artwork, or failing those the first <img> in its HTML.
+func (item RSSItem) imageURL() string {
+	groups := append([]mediaGroup{{
+		Contents:   item.MediaContents,
+		Thumbnails: item.MediaThumbnails,
+	}}, item.MediaGroups...)
+	for _, group := range groups {
+		if image := group.imageURL(); image != "" {
+			return image
+		}
+	}
+	if image := strings.TrimSpace(item.ItunesImage.Href); image != "" {
+		return image
+	}
+	for _, body := range []string{item.Content, item.Description} {
+		if image := firstImage(body, item.Link); image != "" {
+			return image
+		}
+	}
+	return ""
 }
 
+func (group mediaGroup) imageURL() string {
+	for _, thumbnail := range group.Thumbnails {
+		if url := strings.TrimSpace(thumbnail.URL); url != "" {
+			return url
+		}
+	}
+	for _, content := range group.Contents {
+		if len(content.Thumbnails) > 0 && strings.TrimSpace(content.Thumbnails[0].URL) != "" {
+			return strings.TrimSpace(content.Thumbnails[0].URL)
+		}
+		if content.Medium == "image" || strings.HasPrefix(content.Type, "image/") {
+			if url := strings.TrimSpace(content.URL); url != "" {
+				return url
+			}
+		}
+	}
+	return ""
+}
 
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+// firstImage returns the src of the first <img> in an HTML fragment,
+// resolved against base.
+func firstImage(fragment, base string) string {
+	if !strings.Contains(fragment, "<img") && !strings.Contains(fragment, "<IMG") {
+		return ""
+	}
+	doc, err := nethtml.Parse(strings.NewReader(fragment))
 	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("Error creating request to %s:\n%w", feedURL, err)
+		return ""
 	}
-	req.Header.Set("User-Agent", "gator")
+	for node := range doc.Descendants() {
+		if node.Type != nethtml.ElementNode || node.Data != "img" {
+			continue
+		}
+		for _, attr := range node.Attr {
+			if attr.Key != "src" || strings.TrimSpace(attr.Val) == "" || strings.HasPrefix(attr.Val, "data:") {
+				continue
+			}
+			src, err := url.Parse(strings.TrimSpace(attr.Val))
+			if err != nil {
+				return ""
+			}
+			if baseURL, err := url.Parse(base); err == nil {
+				src = baseURL.ResolveReference(src)
+			}
+			return src.String()
+		}
+	}
+	return ""
+}
 
-	client := http.Client{
-		Timeout: 10 * time.Second,
+// durationSeconds parses itunes:duration, which is either a number of seconds
+// or [HH:]MM:SS.
+func (item RSSItem) durationSeconds() (int, bool) {
+	duration := strings.TrimSpace(item.Duration)
+	if duration == "" {
+		return 0, false
 	}
-	res, err := client.Do(req)
+	seconds := 0
+	for _, part := range strings.Split(duration, ":") {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + int(value)
+	}
+	return seconds, true
+}
+
+func (item RSSItem) episodeNumber() (int, bool) {
+	episode, err := strconv.Atoi(strings.TrimSpace(item.Episode))
 	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("Error performing request:\n%w", err)
+		return 0, false
+	}
+	return episode, true
+}
+
+// authorName prefers dc:creator, which is a name, over <author>, which RSS
+// says is an email address optionally followed by the name in parentheses.
+func (item RSSItem) authorName() string {
+	if creator := strings.TrimSpace(item.Creator); creator != "" {
+		return creator
 	}
-	defer res.Body.Close()
+	author := strings.TrimSpace(item.Author)
+	if open := strings.Index(author, "("); open >= 0 && strings.HasSuffix(author, ")") {
+		if name := strings.TrimSpace(author[open+1 : len(author)-1]); name != "" {
+			return name
+		}
+	}
+	return author
+}
+
+// errNotAFeed is returned when a document parses as neither RSS, Atom nor
+// JSON Feed - most often because the URL points at an HTML page.
+var errNotAFeed = errors.New("not an RSS, Atom or JSON feed")
+
+// jsonFeedVersionPrefix starts the version URL every JSON Feed declares,
+// which tells it apart from any other JSON document.
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
 
-	body, err := io.ReadAll(res.Body)
+type jsonFeed struct {
+	Version     string `json:"version"`
+	Title       string `json:"title"`
+	HomePageURL string `json:"home_page_url"`
+	Description string `json:"description"`
+	Items       []struct {
+		URL           string       `json:"url"`
+		Title         string       `json:"title"`
+		ContentHTML   string       `json:"content_html"`
+		ContentText   string       `json:"content_text"`
+		Summary       string       `json:"summary"`
+		DatePublished string       `json:"date_published"`
+		Author        *jsonAuthor  `json:"author"`
+		Authors       []jsonAuthor `json:"authors"`
+		Tags          []string     `json:"tags"`
+		Image         string       `json:"image"`
+		Attachments   []struct {
+			URL               string  `json:"url"`
+			MimeType          string  `json:"mime_type"`
+			SizeInBytes       int64   `json:"size_in_bytes"`
+			DurationInSeconds float64 `json:"duration_in_seconds"`
+		} `json:"attachments"`
+	} `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+// parseFeed parses an RSS, Atom or JSON Feed document into an RSSFeed,
+// decoding it as it's read.  A charset in contentType (the response's
+// Content-Type header, may be empty) wins over the one in the XML
+// declaration.
+func parseFeed(r io.Reader, contentType string) (*RSSFeed, error) {
+	label := contentTypeCharset(contentType)
+	if label != "" {
+		enc, _ := charset.Lookup(label)
+		if enc == nil {
+			return nil, fmt.Errorf("Unsupported charset %q", label)
+		}
+		r = transform.NewReader(r, enc.NewDecoder())
+	}
+	buffered := bufio.NewReader(r)
+	first, err := firstNonSpace(buffered)
 	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("Error reading request body:\n%w", err)
-	} 
+		if errors.Is(err, io.EOF) {
+			return nil, errNotAFeed
+		}
+		return nil, fmt.Errorf("Error reading feed:\n%w", err)
+	}
+	if first == '{' {
+		feed := jsonFeed{}
+		if err := json.NewDecoder(buffered).Decode(&feed); err != nil {
+			return nil, fmt.Errorf("Error unmarshalling json feed:\n%w", err)
+		}
+		if !strings.HasPrefix(feed.Version, jsonFeedVersionPrefix) {
+			return nil, errNotAFeed
+		}
+		rss := jsonFeedToRSS(feed)
+		cleanRSS(rss)
+		return rss, nil
+	}
+
+	decoder := xml.NewDecoder(buffered)
+	decoder.CharsetReader = func(declared string, input io.Reader) (io.Reader, error) {
+		if label != "" {
+			// already decoded to UTF-8 using the Content-Type charset
+			return input, nil
+		}
+		reader, err := charset.NewReaderLabel(declared, input)
+		if err != nil {
+			return nil, fmt.Errorf("Unsupported charset %q", declared)
+		}
+		return reader, nil
+	}
+	root, err := rootElement(decoder)
+	if err != nil {
+		if errors.Is(err, errFeedTooLarge) || errors.Is(err, errFeedTruncated) {
+			return nil, err
+		}
+		return nil, errNotAFeed
+	}
+	switch root.Name.Local {
+	case "rss":
+		rss := RSSFeed{}
+		if err = decoder.DecodeElement(&rss, &root); err != nil {
+			return nil, fmt.Errorf("Error unmarshalling xml:\n%w", err)
+		}
+		cleanRSS(&rss)
+		return &rss, nil
+	case "feed":
+		feed := atomFeed{}
+		if err = decoder.DecodeElement(&feed, &root); err != nil {
+			return nil, fmt.Errorf("Error unmarshalling atom:\n%w", err)
+		}
+		rss := atomToRSS(feed)
+		cleanRSS(rss)
+		return rss, nil
+	}
+	return nil, errNotAFeed
+}
+
+// contentTypeCharset returns the charset parameter of a Content-Type header,
+// or "" if it's missing or just says UTF-8.
+func contentTypeCharset(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	label := strings.ToLower(strings.TrimSpace(params["charset"]))
+	if label == "utf-8" || label == "utf8" {
+		return ""
+	}
+	return label
+}
+
+// firstNonSpace peeks at the first byte of r that isn't whitespace, leaving
+// it unread.
+func firstNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case 0xef:
+			// UTF-8 byte order mark
+			r.Discard(2)
+			continue
+		}
+		return b, r.UnreadByte()
+	}
+}
+
+// rootElement reads up to the first element of an XML document.
+func rootElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+func atomToRSS(feed atomFeed) *RSSFeed {
+	rss := RSSFeed{}
+	rss.Channel.Title = feed.Title
+	rss.Channel.Link = atomAlternateLink(feed.Links)
+	if feed.Subtitle != nil {
+		rss.Channel.Description = feed.Subtitle.Body
+	}
+	for _, entry := range feed.Entries {
+		item := RSSItem{
+			Title:   entry.Title,
+			Link:    atomAlternateLink(entry.Links),
+			PubDate: entry.Published,
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.Updated
+		}
+		if entry.Summary != nil {
+			item.Description = entry.Summary.Body
+		}
+		if entry.Content != nil {
+			item.Content = entry.Content.Body
+			if item.Description == "" {
+				item.Description = entry.Content.Body
+			}
+		}
+		// entries without an author inherit the feed's
+		if entry.Author != nil {
+			item.Creator = entry.Author.Name
+		} else if feed.Author != nil {
+			item.Creator = feed.Author.Name
+		}
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, category.Term)
+		}
+		item.MediaThumbnails = entry.MediaThumbnails
+		item.MediaGroups = entry.MediaGroups
+		for _, link := range entry.Links {
+			switch link.Rel {
+			case "replies":
+				if item.Comments == "" {
+					item.Comments = link.Href
+				}
+			case "enclosure":
+				item.Enclosures = append(item.Enclosures, RSSEnclosure{
+					URL:    link.Href,
+					Length: link.Length,
+					Type:   link.Type,
+				})
+			}
+		}
+		rss.Channel.Item = append(rss.Channel.Item, item)
+	}
+	return &rss
+}
+
+func atomAlternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
 
+func jsonFeedToRSS(feed jsonFeed) *RSSFeed {
 	rss := RSSFeed{}
-	if err = xml.Unmarshal(body, &rss); err != nil {
-		return &RSSFeed{}, fmt.Errorf("Error unmarshalling xml:\n%w", err)
+	rss.Channel.Title = feed.Title
+	rss.Channel.Link = feed.HomePageURL
+	rss.Channel.Description = feed.Description
+	for _, jsonItem := range feed.Items {
+		item := RSSItem{
+			Title:       jsonItem.Title,
+			Link:        jsonItem.URL,
+			Description: jsonItem.Summary,
+			PubDate:     jsonItem.DatePublished,
+		}
+		item.Content = jsonItem.ContentHTML
+		if item.Content == "" {
+			item.Content = jsonItem.ContentText
+		}
+		if item.Description == "" {
+			item.Description = item.Content
+		}
+		if len(jsonItem.Authors) > 0 {
+			item.Creator = jsonItem.Authors[0].Name
+		} else if jsonItem.Author != nil {
+			// JSON Feed 1.0
+			item.Creator = jsonItem.Author.Name
+		}
+		item.Categories = jsonItem.Tags
+		item.ItunesImage.Href = jsonItem.Image
+		for _, attachment := range jsonItem.Attachments {
+			item.Enclosures = append(item.Enclosures, RSSEnclosure{
+				URL:    attachment.URL,
+				Length: strconv.FormatInt(attachment.SizeInBytes, 10),
+				Type:   attachment.MimeType,
+			})
+			if item.Duration == "" && attachment.DurationInSeconds > 0 {
+				item.Duration = strconv.Itoa(int(attachment.DurationInSeconds))
+			}
+		}
+		rss.Channel.Item = append(rss.Channel.Item, item)
 	}
-	cleanRSS(&rss)
-	return &rss, nil
+	return &rss
 }
 
 func cleanRSS(rss *RSSFeed) {
@@ -62,6 +463,11 @@ func cleanRSS(rss *RSSFeed) {
 	for i, rssItem := range rss.Channel.Item {
 		rssItem.Title = html.UnescapeString(rssItem.Title)
 		rssItem.Description = html.UnescapeString(rssItem.Description)
+		rssItem.Creator = html.UnescapeString(rssItem.Creator)
+		rssItem.Author = html.UnescapeString(rssItem.Author)
+		for j, category := range rssItem.Categories {
+			rssItem.Categories[j] = strings.TrimSpace(html.UnescapeString(category))
+		}
 		rss.Channel.Item[i] = rssItem
 	}
 }
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thomas-reed/gator/internal/database"
+)
+
+// ruleActions are what a rule can do with a new post that matches it
+var ruleActions = []string{"star", "read", "tag", "notify"}
+
+// notifyTimeout stops a hung notify_command from holding up agg.
+const notifyTimeout = 10 * time.Second
+
+func handlerRulesAdd(s *state, cmd command, user database.User) error {
+	usage := fmt.Sprintf("Usage: gator %s <star|read|tag <tag>|notify> --match <pattern> [--field <title|description|author|category>] [--feed <feed_url>] [--regex] [--case-sensitive]", cmd.name)
+	args, flags, err := parseFlags(cmd.args, "regex", "case-sensitive")
+	if err != nil {
+		return fmt.Errorf("%w.  %s", err, usage)
+	}
+	if len(args) < 1 || !slices.Contains(ruleActions, args[0]) {
+		return fmt.Errorf("Action required.  %s", usage)
+	}
+	action := args[0]
+	tag := sql.NullString{}
+	if action == "tag" {
+		if len(args) < 2 || strings.TrimSpace(args[1]) == "" {
+			return fmt.Errorf("Tag name required.  %s", usage)
+		}
+		tag = nullString(args[1])
+	}
+	if flags["match"] == "" {
+		return fmt.Errorf("Pattern required.  %s", usage)
+	}
+	field := "any"
+	if flags["field"] != "" {
+		field = flags["field"]
+		if !slices.Contains(filterFields, field) {
+			return fmt.Errorf("Unknown field %s.  %s", field, usage)
+		}
+	}
+
+	feedID := uuid.NullUUID{}
+	if flags["feed"] != "" {
+		feed, err := findFeed(context.Background(), s.db, flags["feed"])
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("No feed found with URL %s", flags["feed"])
+		}
+		if err != nil {
+			return fmt.Errorf("Error getting feed from db:\n%w", err)
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	// an invalid regex would break agg, so postgres checks it first
+	if flags["regex"] != "" {
+		if err := s.db.ValidateRegex(context.Background(), flags["match"]); err != nil {
+			return fmt.Errorf("Invalid regular expression %q:\n%w", flags["match"], err)
+		}
+	}
+
+	rule, err := s.db.CreateRule(context.Background(), database.CreateRuleParams{
+		UserID:        user.ID,
+		FeedID:        feedID,
+		Field:         field,
+		Pattern:       flags["match"],
+		IsRegex:       flags["regex"] != "",
+		CaseSensitive: flags["case-sensitive"] != "",
+		Action:        action,
+		Tag:           tag,
+	})
+	if err != nil {
+		return fmt.Errorf("Error adding rule:\n%w", err)
+	}
+	fmt.Printf("Added rule #%d: %s\n", rule.Seq, describeRule(rule.Action, rule.Tag, rule.Field, rule.Pattern, rule.IsRegex, rule.CaseSensitive))
+	fmt.Printf("Try it on the posts you already have with: gator rules test %d\n", rule.Seq)
+	return nil
+}
+
+func handlerRulesList(s *state, cmd command, user database.User) error {
+	rules, err := s.db.GetRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting rules from db:\n%w", err)
+	}
+	if len(rules) == 0 {
+		fmt.Println("No rules set.  Usage: gator rules add <star|read|tag <tag>|notify> --match <pattern>")
+		return nil
+	}
+	fmt.Println("Rules run on new posts as agg saves them:")
+	for _, rule := range rules {
+		feed := "all feeds"
+		if rule.FeedName.Valid {
+			feed = fmt.Sprintf("'%s' (%s)", stripControl(rule.FeedName.String), stripControl(rule.FeedUrl.String))
+		}
+		fmt.Printf("#%d  %s - on %s\n", rule.Seq, describeRule(rule.Action, rule.Tag, rule.Field, rule.Pattern, rule.IsRegex, rule.CaseSensitive), feed)
+	}
+	return nil
+}
+
+func handlerRulesRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf("Rule ID required.  Usage: gator %s <rule_id>", cmd.name)
+	}
+	seq, err := parseRuleID(cmd.args[0])
+	if err != nil {
+		return err
+	}
+	removed, err := s.db.DeleteRule(context.Background(), database.DeleteRuleParams{
+		UserID: user.ID,
+		Seq:    seq,
+	})
+	if err != nil {
+		return fmt.Errorf("Error removing rule:\n%w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("You have no rule #%d - see gator rules list", seq)
+	}
+	fmt.Printf("Rule #%d removed\n", seq)
+	return nil
+}
+
+func handlerRulesTest(s *state, cmd command, user database.User) error {
+	args, flags, err := parseFlags(cmd.args)
+	if err != nil || len(args) < 1 {
+		return fmt.Errorf("Rule ID required.  Usage: gator %s <rule_id> [--feed <feed_url>] [--limit <n>]", cmd.name)
+	}
+	seq, err := parseRuleID(args[0])
+	if err != nil {
+		return err
+	}
+	limit := 20
+	if flags["limit"] != "" {
+		limit, err = strconv.Atoi(flags["limit"])
+		if err != nil || limit < 1 {
+			return fmt.Errorf("Invalid limit: %s", flags["limit"])
+		}
+	}
+	rule, err := s.db.GetRuleForUser(context.Background(), database.GetRuleForUserParams{
+		UserID: user.ID,
+		Seq:    seq,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("You have no rule #%d - see gator rules list", seq)
+	}
+	if err != nil {
+		return fmt.Errorf("Error getting rule from db:\n%w", err)
+	}
+
+	feedID := uuid.NullUUID{}
+	if flags["feed"] != "" {
+		feed, err := findFeed(context.Background(), s.db, flags["feed"])
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("No feed found with URL %s", flags["feed"])
+		}
+		if err != nil {
+			return fmt.Errorf("Error getting feed from db:\n%w", err)
+		}
+		if rule.FeedID.Valid && rule.FeedID.UUID != feed.ID {
+			return fmt.Errorf("Rule #%d only applies to another feed, not '%s'", seq, feed.Name)
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	posts, err := s.db.GetPostsMatchingRule(context.Background(), database.GetPostsMatchingRuleParams{
+		RuleID: rule.ID,
+		FeedID: feedID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("Error matching posts:\n%w", err)
+	}
+	fmt.Printf("Rule #%d: %s\n", rule.Seq, describeRule(rule.Action, rule.Tag, rule.Field, rule.Pattern, rule.IsRegex, rule.CaseSensitive))
+	if len(posts) == 0 {
+		fmt.Println("No stored posts match.")
+		return nil
+	}
+	fmt.Printf("Stored posts it matches (dry run - nothing was changed, showing at most %d):\n", limit)
+	for _, post := range posts {
+		published := "unknown date"
+		if post.PublishedAt.Valid {
+			published = post.PublishedAt.Time.Format(time.DateOnly)
+		}
+		fmt.Printf("  %d  %s  %s\n", post.Seq, published, stripControl(post.Title))
+	}
+	return nil
+}
+
+// applyRules runs the rules of everyone following feed on a post agg has
+// just saved.  A rule that fails doesn't stop the others from running.
+func applyRules(s *state, feed database.Feed, post database.Post) error {
+	rules, err := s.db.GetMatchingRulesForPost(context.Background(), database.GetMatchingRulesForPostParams{
+		FeedID: feed.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("Error checking rules:\n%w", err)
+	}
+	var errs []error
+	for _, rule := range rules {
+		var err error
+		switch rule.Action {
+		case "star":
+			err = s.db.SetPostStarred(context.Background(), database.SetPostStarredParams{
+				UserID:    rule.UserID,
+				PostID:    post.ID,
+				IsStarred: true,
+			})
+		case "read":
+			err = s.db.SetPostRead(context.Background(), database.SetPostReadParams{
+				UserID: rule.UserID,
+				PostID: post.ID,
+				IsRead: true,
+			})
+		case "tag":
+			err = s.db.CreatePostTag(context.Background(), database.CreatePostTagParams{
+				UserID: rule.UserID,
+				PostID: post.ID,
+				Name:   rule.Tag.String,
+			})
+		case "notify":
+			notify(s, rule, feed, post)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Error applying rule #%d:\n%w", rule.Seq, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notify prints a matched post and, if notify_command is set in the config,
+// runs it with the post's details in GATOR_* environment variables.
+func notify(s *state, rule database.GetMatchingRulesForPostRow, feed database.Feed, post database.Post) {
+	fmt.Printf("Rule #%d for %s matched '%s' from '%s': %s\n", rule.Seq, rule.UserName, stripControl(post.Title), stripControl(feed.Name), stripControl(post.Url))
+	if s.cfg.NotifyCommand == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.cfg.NotifyCommand)
+	cmd.Env = append(os.Environ(),
+		"GATOR_USER="+rule.UserName,
+		"GATOR_RULE="+strconv.FormatInt(rule.Seq, 10),
+		"GATOR_FEED="+stripControl(feed.Name),
+		"GATOR_TITLE="+stripControl(post.Title),
+		"GATOR_URL="+stripControl(post.Url),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		// a broken notifier shouldn't stop posts being saved
+		fmt.Printf("notify_command failed: %v\n", err)
+	}
+}
+
+func describeRule(action string, tag sql.NullString, field, pattern string, isRegex, caseSensitive bool) string {
+	verb := action
+	switch action {
+	case "read":
+		verb = "mark read"
+	case "tag":
+		verb = fmt.Sprintf("tag %q", tag.String)
+	case "notify":
+		verb = "notify about"
+	}
+	return verb + " " + describeMatch(field, pattern, isRegex, caseSensitive)
+}
+
+func parseRuleID(arg string) (int64, error) {
+	seq, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid rule ID %s - see gator rules list", arg)
+	}
+	return seq, nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags are kept along with the attributes listed for them.  Tags not
+// listed here are unwrapped, keeping their content, unless they're in
+// droppedTags.
+var allowedTags = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"audio":      {"src", "controls"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"caption":    nil,
+	"cite":       nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        {"cite", "datetime"},
+	"details":    nil,
+	"div":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        {"cite", "datetime"},
+	"kbd":        nil,
+	"li":         nil,
+	"mark":       nil,
+	"ol":         {"start"},
+	"p":          nil,
+	"picture":    nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"small":      nil,
+	"source":     {"src", "type"},
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"summary":    nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan", "scope"},
+	"thead":      nil,
+	"time":       {"datetime"},
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+	"video":      {"src", "poster", "controls", "width", "height"},
+}
+
+// droppedTags are removed along with everything inside them.
+var droppedTags = map[string]bool{
+	"base": true, "button": true, "embed": true, "form": true, "frame": true,
+	"frameset": true, "head": true, "iframe": true, "input": true, "link": true,
+	"math": true, "meta": true, "noscript": true, "object": true, "script": true,
+	"select": true, "style": true, "svg": true, "template": true, "textarea": true,
+	"title": true,
+}
+
+// urlAttrs hold URLs, which are resolved against the base URL and dropped
+// unless they're http(s) (or mailto for links).
+var urlAttrs = map[string]bool{
+	"href": true, "src": true, "cite": true, "poster": true,
+}
+
+// trackerURLs are substrings of image URLs that only exist to count views.
+var trackerURLs = []string{
+	"feeds.feedburner.com/~r/",
+	"feeds.feedburner.com/~ff/",
+	"feedsportal.com",
+	"pixel.wp.com",
+	"stats.wordpress.com",
+	"doubleclick.net",
+	"google-analytics.com",
+	"/open.aspx",
+	"/track/open",
+}
+
+// sanitizeHTML strips fragment down to allowedTags, removes tracking pixels
+// and makes relative links and image sources absolute using base.
+func sanitizeHTML(fragment, base string) string {
+	if strings.TrimSpace(fragment) == "" {
+		return ""
+	}
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		// not worth keeping anything we can't check
+		return html.EscapeString(fragment)
+	}
+	baseURL, _ := url.Parse(base)
+
+	var sb strings.Builder
+	for _, node := range nodes {
+		writeSanitized(&sb, node, baseURL)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func writeSanitized(sb *strings.Builder, node *html.Node, base *url.URL) {
+	switch node.Type {
+	case html.TextNode:
+		sb.WriteString(html.EscapeString(node.Data))
+		return
+	case html.ElementNode:
+	default:
+		// comments, doctypes
+		return
+	}
+
+	tag := node.Data
+	if droppedTags[tag] || (tag == "img" && isTrackingPixel(node)) {
+		return
+	}
+	allowed, ok := allowedTags[tag]
+	if !ok {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			writeSanitized(sb, child, base)
+		}
+		return
+	}
+
+	attrs := []html.Attribute{}
+	for _, a := range node.Attr {
+		key := strings.ToLower(a.Key)
+		if a.Namespace != "" || !slices.Contains(allowed, key) {
+			continue
+		}
+		value := a.Val
+		if urlAttrs[key] {
+			value = safeURL(value, base, key == "href")
+			if value == "" {
+				continue
+			}
+		}
+		attrs = append(attrs, html.Attribute{Key: key, Val: value})
+	}
+	if tag == "a" {
+		attrs = append(attrs, html.Attribute{Key: "rel", Val: "noopener noreferrer"})
+	}
+	if tag == "img" && !hasAttr(attrs, "src") {
+		return
+	}
+
+	sb.WriteString("<" + tag)
+	for _, a := range attrs {
+		sb.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	sb.WriteString(">")
+	if isVoidElement(tag) {
+		return
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeSanitized(sb, child, base)
+	}
+	sb.WriteString("</" + tag + ">")
+}
+
+// safeURL resolves raw against base, returning "" for schemes that could run
+// code (javascript:, data:, ...).
+func safeURL(raw string, base *url.URL, isLink bool) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	if base != nil && base.IsAbs() {
+		u = base.ResolveReference(u)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.String()
+	case "mailto":
+		if isLink {
+			return u.String()
+		}
+	case "":
+		// still relative, with no base to resolve it against
+		if !strings.HasPrefix(strings.TrimSpace(raw), "//") {
+			return u.String()
+		}
+	}
+	return ""
+}
+
+// resolveURL resolves ref against base, returning ref unchanged if either
+// doesn't parse.
+func resolveURL(base, ref string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+func isTrackingPixel(node *html.Node) bool {
+	width, height := -1, -1
+	for _, a := range node.Attr {
+		switch strings.ToLower(a.Key) {
+		case "width":
+			width = pixelSize(a.Val)
+		case "height":
+			height = pixelSize(a.Val)
+		case "src":
+			for _, tracker := range trackerURLs {
+				if strings.Contains(a.Val, tracker) {
+					return true
+				}
+			}
+		}
+	}
+	return width >= 0 && width <= 1 && height >= 0 && height <= 1
+}
+
+// pixelSize reads a width or height attribute in pixels, or -1 if it isn't
+// a plain number (100%, auto, ...), so an unknown size never looks tiny.
+func pixelSize(value string) int {
+	size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "px"))
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+func isVoidElement(tag string) bool {
+	switch tag {
+	case "br", "hr", "img", "source":
+		return true
+	}
+	return false
+}
+
+func hasAttr(attrs []html.Attribute, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
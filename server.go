@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thomas-reed/gator/internal/database"
+)
+
+func handlerServe(s *state, cmd command) error {
+	addr := ":8080"
+	if len(cmd.args) >= 1 {
+		addr = cmd.args[0]
+	}
+
+	mux := http.NewServeMux()
+	registerReaderAPI(s, mux)
+	mux.HandleFunc("GET /users/{name}/feed.atom", func(w http.ResponseWriter, r *http.Request) {
+		handlerUserAtom(s, w, r)
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Printf("Serving gator on %s...\n", addr)
+	if err := server.ListenAndServe(); err != nil {
+		return fmt.Errorf("Error running server:\n%w", err)
+	}
+	return nil
+}
+
+func handlerUserAtom(s *state, w http.ResponseWriter, r *http.Request) {
+	user, err := s.db.GetUserByName(r.Context(), strings.ToLower(r.PathValue("name")))
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	posts, err := s.db.GetPostsForUser(r.Context(), database.GetPostsForUserParams{
+		UserID: user.ID,
+		Limit:  50,
+	})
+	if err != nil {
+		writeServerError(w, "Error getting posts", err)
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	data, err := renderUserAtom(user, posts, fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.Path))
+	if err != nil {
+		writeServerError(w, "Error rendering atom feed", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshalling JSON response: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeServerError(w http.ResponseWriter, msg string, err error) {
+	log.Printf("%s: %v", msg, err)
+	http.Error(w, msg, http.StatusInternalServerError)
+}
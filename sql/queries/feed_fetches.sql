@@ -0,0 +1,26 @@
+-- name: CreateFeedFetch :exec
+INSERT INTO feed_fetches (id, feed_id, url, status_code, error, created_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  $4,
+  NOW() AT TIME ZONE 'UTC'
+);
+
+-- name: GetFeedFetches :many
+SELECT * FROM feed_fetches
+WHERE feed_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: PruneFeedFetches :exec
+DELETE FROM feed_fetches
+WHERE feed_id = @feed_id
+  AND id NOT IN (
+    SELECT id FROM feed_fetches AS kept
+    WHERE kept.feed_id = @feed_id
+    ORDER BY kept.created_at DESC
+    LIMIT @keep
+  );
@@ -21,7 +21,10 @@ INNER JOIN feeds ON inserted_feed_follow.feed_id = feeds.id;
 -- name: GetFeedFollowsByUser :many
 SELECT
   users.name AS user_name,
-  feeds.name AS feed_name
+  COALESCE(feed_follows.title, feeds.name) AS feed_name,
+  feeds.id AS feed_id,
+  feeds.url AS feed_url,
+  feed_follows.tags
 FROM feed_follows
 INNER JOIN users ON feed_follows.user_id = users.id
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
@@ -29,4 +32,65 @@ WHERE users.id = $1;
 
 -- name: DeleteFeedFollowByUserAndName :exec
 DELETE FROM feed_follows
-WHERE user_id = $1 AND feed_id = $2;
\ No newline at end of file
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: GetNextFeedOwner :one
+SELECT users.id, users.name FROM feed_follows
+INNER JOIN users ON feed_follows.user_id = users.id
+WHERE feed_follows.feed_id = $1 AND feed_follows.user_id <> $2
+ORDER BY feed_follows.created_at ASC
+LIMIT 1;
+
+-- name: CountFeedFollowsForUser :one
+SELECT COUNT(*) FROM feed_follows
+WHERE user_id = $1;
+
+-- name: CountFollowersForFeed :one
+SELECT COUNT(*) FROM feed_follows
+WHERE feed_id = $1;
+
+-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (id, user_id, feed_id, keep_episodes, tags, title, created_at, updated_at)
+SELECT
+  gen_random_uuid(),
+  user_id,
+  @to_feed_id::uuid,
+  keep_episodes,
+  tags,
+  title,
+  created_at,
+  NOW() AT TIME ZONE 'UTC'
+FROM feed_follows
+WHERE feed_id = @from_feed_id
+ON CONFLICT (user_id, feed_id) DO UPDATE
+SET
+  tags = ARRAY(
+    SELECT DISTINCT unnest(feed_follows.tags || EXCLUDED.tags)
+    ORDER BY 1
+  ),
+  title = COALESCE(feed_follows.title, EXCLUDED.title);
+
+-- name: GetFeedFollow :one
+SELECT * FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: SetFeedFollowKeepEpisodes :exec
+UPDATE feed_follows
+SET
+  keep_episodes = $3,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: SetFeedFollowTags :exec
+UPDATE feed_follows
+SET
+  tags = $3,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: SetFeedFollowTitle :exec
+UPDATE feed_follows
+SET
+  title = $3,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE user_id = $1 AND feed_id = $2;
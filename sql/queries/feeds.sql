@@ -14,7 +14,8 @@ RETURNING *;
 SELECT
   feeds.name AS feed_name,
   feeds.url AS feed_url,
-  users.name AS user_name
+  users.name AS user_name,
+  feeds.dead_at
 FROM feeds
 INNER JOIN users ON feeds.user_id = users.id;
 
@@ -25,11 +26,71 @@ SELECT * FROM feeds WHERE url = $1;
 UPDATE feeds
 SET
   last_fetched_at = NOW() AT TIME ZONE 'UTC',
+  retry_after = NULL,
   updated_at = NOW() AT TIME ZONE 'UTC'
 WHERE id = $1
 RETURNING *;
 
 -- name: GetNextFeedToFetch :one
 SELECT * FROM feeds
+WHERE dead_at IS NULL
+  AND (retry_after IS NULL OR retry_after <= NOW() AT TIME ZONE 'UTC')
 ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+
+-- name: ResetFeeds :exec
+DELETE FROM feeds;
+
+-- name: GetFeedsByUser :many
+SELECT * FROM feeds
+WHERE user_id = $1;
+
+-- name: SetFeedOwner :exec
+UPDATE feeds
+SET
+  user_id = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1;
+
+-- name: RenameFeed :one
+UPDATE feeds
+SET
+  name = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+RETURNING *;
+
+-- name: SetFeedURL :one
+UPDATE feeds
+SET
+  url = $2,
+  dead_at = NULL,
+  retry_after = NULL,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: MarkFeedDead :exec
+UPDATE feeds
+SET
+  dead_at = NOW() AT TIME ZONE 'UTC',
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1;
+
+-- name: SetFeedRetryAfter :exec
+UPDATE feeds
+SET
+  retry_after = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1;
+
+-- name: SetFeedFetchFullText :exec
+UPDATE feeds
+SET
+  fetch_full_text = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1;
@@ -0,0 +1,36 @@
+-- name: CreateFilter :one
+INSERT INTO filters (id, user_id, feed_id, action, field, pattern, is_regex, case_sensitive, created_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  NOW() AT TIME ZONE 'UTC'
+)
+RETURNING *;
+
+-- name: GetFiltersForUser :many
+SELECT
+  filters.*,
+  feeds.name AS feed_name,
+  feeds.url AS feed_url
+FROM filters
+LEFT JOIN feeds ON filters.feed_id = feeds.id
+WHERE filters.user_id = $1
+ORDER BY filters.seq;
+
+-- name: DeleteFilter :execrows
+DELETE FROM filters
+WHERE user_id = $1 AND seq = $2;
+
+-- name: MoveFilters :exec
+UPDATE filters
+SET feed_id = @to_feed_id
+WHERE feed_id = @from_feed_id;
+
+-- name: ValidateRegex :exec
+SELECT ''::text ~ sqlc.arg('pattern')::text;
@@ -0,0 +1,9 @@
+-- name: CreatePostCategory :exec
+INSERT INTO post_categories (id, post_id, name, created_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  NOW() AT TIME ZONE 'UTC'
+)
+ON CONFLICT (post_id, name) DO NOTHING;
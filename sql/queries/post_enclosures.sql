@@ -0,0 +1,26 @@
+-- name: CreatePostEnclosure :exec
+INSERT INTO post_enclosures (id, post_id, url, length, mime_type, created_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  $4,
+  NOW() AT TIME ZONE 'UTC'
+)
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: GetEnclosuresForPost :many
+SELECT * FROM post_enclosures
+WHERE post_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetEnclosuresForFeed :many
+SELECT
+  post_enclosures.*,
+  posts.title AS post_title,
+  posts.seq AS post_seq
+FROM post_enclosures
+INNER JOIN posts ON post_enclosures.post_id = posts.id
+WHERE posts.feed_id = $1
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, post_enclosures.created_at ASC;
@@ -0,0 +1,61 @@
+-- name: SetPostRead :exec
+INSERT INTO post_states (id, user_id, post_id, is_read, created_at, updated_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  NOW() AT TIME ZONE 'UTC',
+  NOW() AT TIME ZONE 'UTC'
+)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET
+  is_read = EXCLUDED.is_read,
+  updated_at = EXCLUDED.updated_at;
+
+-- name: SetPostStarred :exec
+INSERT INTO post_states (id, user_id, post_id, is_starred, created_at, updated_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  NOW() AT TIME ZONE 'UTC',
+  NOW() AT TIME ZONE 'UTC'
+)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET
+  is_starred = EXCLUDED.is_starred,
+  updated_at = EXCLUDED.updated_at;
+
+-- name: MarkAllPostsRead :exec
+INSERT INTO post_states (id, user_id, post_id, is_read, created_at, updated_at)
+SELECT
+  gen_random_uuid(),
+  feed_follows.user_id,
+  posts.id,
+  TRUE,
+  NOW() AT TIME ZONE 'UTC',
+  NOW() AT TIME ZONE 'UTC'
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = @user_id
+  AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+  AND (sqlc.narg('label')::text IS NULL OR sqlc.narg('label') = ANY(feed_follows.tags))
+  AND posts.created_at <= @before
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET
+  is_read = TRUE,
+  updated_at = EXCLUDED.updated_at;
+
+-- name: GetUnreadCountsForUser :many
+SELECT
+  feeds.url AS feed_url,
+  COUNT(*) AS unread_count,
+  MAX(COALESCE(posts.published_at, posts.created_at))::timestamp AS newest_item
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON posts.id = post_states.post_id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND NOT COALESCE(post_states.is_read, FALSE)
+GROUP BY feeds.url;
@@ -0,0 +1,10 @@
+-- name: CreatePostTag :exec
+INSERT INTO post_tags (id, user_id, post_id, name, created_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  NOW() AT TIME ZONE 'UTC'
+)
+ON CONFLICT (user_id, post_id, name) DO NOTHING;
@@ -1,5 +1,5 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, title, url, published_at, description, feed_id, created_at, updated_at)
+INSERT INTO posts (id, title, url, published_at, description, feed_id, content, author, comments_url, duration_seconds, episode, image_url, created_at, updated_at)
 VALUES (
   gen_random_uuid(),
   $1,
@@ -7,19 +7,137 @@ VALUES (
   $3,
   $4,
   $5,
+  $6,
+  $7,
+  $8,
+  $9,
+  $10,
+  $11,
   NOW() AT TIME ZONE 'UTC',
   NOW() AT TIME ZONE 'UTC'
 )
 RETURNING *;
 
 -- name: GetPostsForUser :many
-SELECT posts.*, feeds.name AS feed_name  FROM posts
+SELECT
+  posts.*,
+  COALESCE(feed_follows.title, feeds.name) AS feed_name,
+  feeds.url AS feed_url,
+  ARRAY(
+    SELECT post_categories.name FROM post_categories
+    WHERE post_categories.post_id = posts.id
+    ORDER BY post_categories.name
+  )::text[] AS categories,
+  ARRAY(
+    SELECT post_tags.name FROM post_tags
+    WHERE post_tags.post_id = posts.id AND post_tags.user_id = @user_id
+    ORDER BY post_tags.name
+  )::text[] AS tags
+FROM posts
 INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
 INNER JOIN feeds ON posts.feed_id = feeds.id
-WHERE feed_follows.user_id = $1
+WHERE feed_follows.user_id = @user_id
+  AND (sqlc.narg('author')::text IS NULL OR posts.author ILIKE '%' || sqlc.narg('author') || '%')
+  AND (sqlc.narg('category')::text IS NULL OR EXISTS (
+    SELECT 1 FROM post_categories
+    WHERE post_categories.post_id = posts.id
+      AND lower(post_categories.name) = lower(sqlc.narg('category'))
+  ))
+  AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+    SELECT 1 FROM unnest(feed_follows.tags) AS follow_tag
+    WHERE lower(follow_tag) = lower(sqlc.narg('tag'))
+  ) OR EXISTS (
+    SELECT 1 FROM post_tags
+    WHERE post_tags.post_id = posts.id
+      AND post_tags.user_id = @user_id
+      AND lower(post_tags.name) = lower(sqlc.narg('tag'))
+  ))
+  AND (@unfiltered::boolean OR (
+    NOT EXISTS (
+      SELECT 1 FROM filters
+      WHERE filters.user_id = @user_id
+        AND filters.action = 'exclude'
+        AND (filters.feed_id IS NULL OR filters.feed_id = posts.feed_id)
+        AND post_matches(posts.id, filters.field, filters.pattern, filters.is_regex, filters.case_sensitive)
+    )
+    AND (
+      NOT EXISTS (
+        SELECT 1 FROM filters
+        WHERE filters.user_id = @user_id
+          AND filters.action = 'include'
+          AND (filters.feed_id IS NULL OR filters.feed_id = posts.feed_id)
+      )
+      OR EXISTS (
+        SELECT 1 FROM filters
+        WHERE filters.user_id = @user_id
+          AND filters.action = 'include'
+          AND (filters.feed_id IS NULL OR filters.feed_id = posts.feed_id)
+          AND post_matches(posts.id, filters.field, filters.pattern, filters.is_regex, filters.case_sensitive)
+      )
+    )
+  ))
 ORDER BY published_at DESC NULLS LAST
-LIMIT $2;
+LIMIT sqlc.arg('limit');
 
 -- name: GetPostByURL :one
 SELECT * FROM posts
 WHERE url = $1;
+
+-- name: GetPostByID :one
+SELECT * FROM posts
+WHERE id = $1;
+
+-- name: GetPostBySeq :one
+SELECT * FROM posts
+WHERE seq = $1;
+
+-- name: GetReaderItems :many
+SELECT
+  posts.*,
+  COALESCE(feed_follows.title, feeds.name) AS feed_name,
+  feeds.url AS feed_url,
+  COALESCE(post_states.is_read, FALSE)::boolean AS is_read,
+  COALESCE(post_states.is_starred, FALSE)::boolean AS is_starred
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_states ON posts.id = post_states.post_id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = @user_id
+  AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+  AND (sqlc.narg('label')::text IS NULL OR sqlc.narg('label') = ANY(feed_follows.tags))
+  AND (COALESCE(cardinality(@seqs::bigint[]), 0) = 0 OR posts.seq = ANY(@seqs::bigint[]))
+  AND (NOT @exclude_read::boolean OR NOT COALESCE(post_states.is_read, FALSE))
+  AND (NOT @only_read::boolean OR COALESCE(post_states.is_read, FALSE))
+  AND (NOT @only_starred::boolean OR COALESCE(post_states.is_starred, FALSE))
+  AND (sqlc.narg('older_than')::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg('older_than'))
+  AND (sqlc.narg('newer_than')::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) > sqlc.narg('newer_than'))
+ORDER BY
+  CASE WHEN @oldest_first::boolean THEN COALESCE(posts.published_at, posts.created_at) END ASC,
+  COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: GetPostIDsBySeqs :many
+SELECT posts.id FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = @user_id AND posts.seq = ANY(@seqs::bigint[]);
+
+-- name: ResetPosts :exec
+DELETE FROM posts;
+
+-- name: CountPostsForFeed :one
+SELECT COUNT(*) FROM posts
+WHERE feed_id = $1;
+
+-- name: MovePosts :exec
+UPDATE posts
+SET
+  feed_id = @to_feed_id,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE feed_id = @from_feed_id;
+
+-- name: SetPostFullContent :exec
+UPDATE posts
+SET
+  full_content = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1;
@@ -0,0 +1,60 @@
+-- name: CreateRule :one
+INSERT INTO rules (id, user_id, feed_id, field, pattern, is_regex, case_sensitive, action, tag, created_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  $8,
+  NOW() AT TIME ZONE 'UTC'
+)
+RETURNING *;
+
+-- name: GetRulesForUser :many
+SELECT
+  rules.*,
+  feeds.name AS feed_name,
+  feeds.url AS feed_url
+FROM rules
+LEFT JOIN feeds ON rules.feed_id = feeds.id
+WHERE rules.user_id = $1
+ORDER BY rules.seq;
+
+-- name: GetRuleForUser :one
+SELECT * FROM rules
+WHERE user_id = $1 AND seq = $2;
+
+-- name: DeleteRule :execrows
+DELETE FROM rules
+WHERE user_id = $1 AND seq = $2;
+
+-- name: MoveRules :exec
+UPDATE rules
+SET feed_id = @to_feed_id
+WHERE feed_id = @from_feed_id;
+
+-- name: GetMatchingRulesForPost :many
+SELECT
+  rules.*,
+  users.name AS user_name
+FROM rules
+INNER JOIN feed_follows ON feed_follows.user_id = rules.user_id AND feed_follows.feed_id = @feed_id
+INNER JOIN users ON rules.user_id = users.id
+WHERE (rules.feed_id IS NULL OR rules.feed_id = @feed_id)
+  AND post_matches(@post_id, rules.field, rules.pattern, rules.is_regex, rules.case_sensitive)
+ORDER BY rules.seq;
+
+-- name: GetPostsMatchingRule :many
+SELECT posts.*
+FROM posts
+INNER JOIN rules ON rules.id = @rule_id
+INNER JOIN feed_follows ON feed_follows.user_id = rules.user_id AND feed_follows.feed_id = posts.feed_id
+WHERE (rules.feed_id IS NULL OR rules.feed_id = posts.feed_id)
+  AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+  AND post_matches(posts.id, rules.field, rules.pattern, rules.is_regex, rules.case_sensitive)
+ORDER BY posts.published_at DESC NULLS LAST
+LIMIT sqlc.arg('limit');
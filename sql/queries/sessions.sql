@@ -0,0 +1,24 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, token_hash, user_id, expires_at, created_at, updated_at)
+VALUES (
+  gen_random_uuid(),
+  $1,
+  $2,
+  $3,
+  NOW() AT TIME ZONE 'UTC',
+  NOW() AT TIME ZONE 'UTC'
+)
+RETURNING *;
+
+-- name: GetUserBySessionToken :one
+SELECT users.* FROM sessions
+INNER JOIN users ON sessions.user_id = users.id
+WHERE sessions.token_hash = $1 AND sessions.expires_at > NOW() AT TIME ZONE 'UTC';
+
+-- name: DeleteSession :exec
+DELETE FROM sessions
+WHERE token_hash = $1;
+
+-- name: DeleteSessionsForUser :exec
+DELETE FROM sessions
+WHERE user_id = $1;
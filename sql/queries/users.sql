@@ -1,8 +1,10 @@
 -- name: CreateUser :one
-INSERT INTO users (id, name, created_at, updated_at)
+INSERT INTO users (id, name, hashed_password, role, created_at, updated_at)
 VALUES (
   gen_random_uuid(),
   $1,
+  $2,
+  CASE WHEN EXISTS (SELECT 1 FROM users) THEN 'member' ELSE 'admin' END,
   NOW() AT TIME ZONE 'UTC',
   NOW() AT TIME ZONE 'UTC'
 )
@@ -16,4 +18,46 @@ WHERE name = $1;
 DELETE FROM users;
 
 -- name: GetUsers :many
-SELECT name FROM users;
\ No newline at end of file
+SELECT name, role FROM users;
+
+-- name: GetUserByAPIKeyHash :one
+SELECT * FROM users
+WHERE api_key_hash = $1;
+
+-- name: SetUserAPIKeyHash :exec
+UPDATE users
+SET
+  api_key_hash = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1;
+
+-- name: SetUserPassword :exec
+UPDATE users
+SET
+  hashed_password = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1;
+
+-- name: SetUserRole :one
+UPDATE users
+SET
+  role = $2,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE name = $1
+RETURNING *;
+
+-- name: CountAdmins :one
+SELECT COUNT(*) FROM users
+WHERE role = 'admin';
+
+-- name: RenameUser :one
+UPDATE users
+SET
+  name = @new_name,
+  updated_at = NOW() AT TIME ZONE 'UTC'
+WHERE name = @old_name
+RETURNING *;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
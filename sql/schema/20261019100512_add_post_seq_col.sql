@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts
+ADD COLUMN seq BIGSERIAL NOT NULL UNIQUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE posts
+DROP COLUMN seq;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'member'
+CHECK (role IN ('admin', 'member'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE users
+SET role = 'admin'
+WHERE id = (SELECT id FROM users ORDER BY created_at ASC LIMIT 1);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+DROP COLUMN role;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE feeds
+ADD COLUMN dead_at TIMESTAMP,
+ADD COLUMN retry_after TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE feed_fetches (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+  url TEXT NOT NULL,
+  status_code INTEGER,
+  error TEXT
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE feed_fetches;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE feeds
+DROP COLUMN dead_at,
+DROP COLUMN retry_after;
+-- +goose StatementEnd
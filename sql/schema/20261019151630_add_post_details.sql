@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts
+ADD COLUMN content TEXT,
+ADD COLUMN author TEXT,
+ADD COLUMN comments_url TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE post_categories (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  CONSTRAINT post_category_unique UNIQUE(post_id, name)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE post_categories;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE posts
+DROP COLUMN content,
+DROP COLUMN author,
+DROP COLUMN comments_url;
+-- +goose StatementEnd
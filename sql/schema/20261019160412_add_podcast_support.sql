@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE post_enclosures (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  url TEXT NOT NULL,
+  length BIGINT,
+  mime_type TEXT,
+  CONSTRAINT post_enclosure_unique UNIQUE(post_id, url)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE posts
+ADD COLUMN duration_seconds INTEGER,
+ADD COLUMN episode INTEGER,
+ADD COLUMN image_url TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE feed_follows
+ADD COLUMN keep_episodes INTEGER;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE feed_follows
+DROP COLUMN keep_episodes;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE posts
+DROP COLUMN duration_seconds,
+DROP COLUMN episode,
+DROP COLUMN image_url;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE post_enclosures;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE feeds
+ADD COLUMN fetch_full_text BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE posts
+ADD COLUMN full_content TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE posts
+DROP COLUMN full_content;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE feeds
+DROP COLUMN fetch_full_text;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE filters (
+  id UUID PRIMARY KEY,
+  seq BIGSERIAL NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+  action TEXT NOT NULL CHECK (action IN ('include', 'exclude')),
+  field TEXT NOT NULL CHECK (field IN ('any', 'title', 'description', 'author', 'category')),
+  pattern TEXT NOT NULL,
+  is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+  case_sensitive BOOLEAN NOT NULL DEFAULT FALSE
+);
+-- +goose StatementEnd
+
+-- post_matches checks whether a post's field ('any' for all of them)
+-- contains pattern, or matches it if it's a regex
+-- +goose StatementBegin
+CREATE FUNCTION post_matches(post_id UUID, field TEXT, pattern TEXT, is_regex BOOLEAN, case_sensitive BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE SQL STABLE
+AS $$
+  SELECT EXISTS (
+    SELECT 1
+    FROM posts
+    LEFT JOIN post_categories ON post_categories.post_id = posts.id
+    CROSS JOIN LATERAL (VALUES
+      ('title', posts.title),
+      ('description', posts.description),
+      ('description', posts.content),
+      ('author', posts.author),
+      ('category', post_categories.name)
+    ) AS fields(name, value)
+    WHERE posts.id = $1
+      AND ($2 = 'any' OR fields.name = $2)
+      AND fields.value IS NOT NULL
+      AND CASE
+        WHEN $4 AND $5 THEN fields.value ~ $3
+        WHEN $4 THEN fields.value ~* $3
+        WHEN $5 THEN strpos(fields.value, $3) > 0
+        ELSE strpos(lower(fields.value), lower($3)) > 0
+      END
+  )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION post_matches;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE filters;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE rules (
+  id UUID PRIMARY KEY,
+  seq BIGSERIAL NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+  field TEXT NOT NULL CHECK (field IN ('any', 'title', 'description', 'author', 'category')),
+  pattern TEXT NOT NULL,
+  is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+  case_sensitive BOOLEAN NOT NULL DEFAULT FALSE,
+  action TEXT NOT NULL CHECK (action IN ('star', 'read', 'tag', 'notify')),
+  tag TEXT,
+  CONSTRAINT rule_tag_required CHECK (action <> 'tag' OR tag IS NOT NULL)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE post_tags (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  CONSTRAINT post_tag_unique UNIQUE(user_id, post_id, name)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE post_tags;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE rules;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE feed_follows
+ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE feed_follows
+DROP COLUMN tags;
+-- +goose StatementEnd
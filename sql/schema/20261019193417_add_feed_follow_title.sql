@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE feed_follows
+ADD COLUMN title TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE feed_follows
+DROP COLUMN title;
+-- +goose StatementEnd
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/thomas-reed/gator/internal/database"
+)
+
+func handlerTagAdd(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Feed URL and tag required.  Usage: gator %s <feed_url> <tag>", cmd.name)
+	}
+	tag, err := normalizeTag(cmd.args[1])
+	if err != nil {
+		return err
+	}
+	feed, follow, err := getFollow(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	if hasTag(follow.Tags, tag) {
+		fmt.Printf("'%s' is already tagged %s\n", stripControl(feed.Name), tag)
+		return nil
+	}
+	if err := s.db.SetFeedFollowTags(context.Background(), database.SetFeedFollowTagsParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+		Tags:   addTag(follow.Tags, tag),
+	}); err != nil {
+		return fmt.Errorf("Error saving tags:\n%w", err)
+	}
+	fmt.Printf("Tagged '%s' %s\n", stripControl(feed.Name), tag)
+	return nil
+}
+
+func handlerTagRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Feed URL and tag required.  Usage: gator %s <feed_url> <tag>", cmd.name)
+	}
+	feed, follow, err := getFollow(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	tag := strings.TrimSpace(cmd.args[1])
+	if !hasTag(follow.Tags, tag) {
+		return fmt.Errorf("'%s' isn't tagged %s", feed.Name, tag)
+	}
+	tags := slices.DeleteFunc(follow.Tags, func(t string) bool {
+		return strings.EqualFold(t, tag)
+	})
+	if err := s.db.SetFeedFollowTags(context.Background(), database.SetFeedFollowTagsParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+		Tags:   tags,
+	}); err != nil {
+		return fmt.Errorf("Error saving tags:\n%w", err)
+	}
+	fmt.Printf("Removed tag %s from '%s'\n", tag, stripControl(feed.Name))
+	return nil
+}
+
+// getFollow looks up a feed by URL along with the user's follow of it.
+func getFollow(s *state, user database.User, rawURL string) (database.Feed, database.FeedFollow, error) {
+	feed, err := findFeed(context.Background(), s.db, rawURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, database.FeedFollow{}, fmt.Errorf("No feed found with URL %s", rawURL)
+	}
+	if err != nil {
+		return database.Feed{}, database.FeedFollow{}, fmt.Errorf("Error getting feed from db:\n%w", err)
+	}
+	follow, err := s.db.GetFeedFollow(context.Background(), database.GetFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, database.FeedFollow{}, fmt.Errorf("You don't follow '%s'.  Usage: gator follow %s", feed.Name, feed.Url)
+	}
+	if err != nil {
+		return database.Feed{}, database.FeedFollow{}, fmt.Errorf("Error getting feed follow from db:\n%w", err)
+	}
+	return feed, follow, nil
+}
+
+// normalizeTag trims a tag and rejects ones that can't be written to OPML,
+// which separates categories with commas and nests them with slashes.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", errors.New("Tag can't be empty")
+	}
+	if strings.ContainsAny(tag, ",/") {
+		return "", fmt.Errorf("Invalid tag %s - tags can't contain commas or slashes", tag)
+	}
+	return tag, nil
+}
+
+// hasTag reports whether tags contains tag, ignoring case.
+func hasTag(tags []string, tag string) bool {
+	return slices.ContainsFunc(tags, func(t string) bool {
+		return strings.EqualFold(t, tag)
+	})
+}
+
+// addTag returns tags with tag added, sorted.  The result is never nil, so
+// it's saved as an empty array rather than NULL.
+func addTag(tags []string, tag string) []string {
+	result := append([]string{}, tags...)
+	if !hasTag(result, tag) {
+		result = append(result, tag)
+	}
+	slices.Sort(result)
+	return result
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/thomas-reed/gator/internal/database"
+	"golang.org/x/term"
+)
+
+const (
+	paneFeeds = iota
+	panePosts
+	paneReader
+)
+
+const (
+	// tuiRefresh is how often the TUI reloads from the database, to pick up
+	// posts saved by an agg running elsewhere
+	tuiRefresh   = 5 * time.Second
+	tuiPostLimit = 500
+
+	ansiReverse     = "\x1b[7m"
+	ansiClearScreen = "\x1b[2J"
+	ansiHome        = "\x1b[H"
+	ansiAltScreen   = "\x1b[?1049h\x1b[?25l"
+	ansiMainScreen  = "\x1b[?25h\x1b[?1049l"
+)
+
+const tuiHelp = "j/k move  enter open  tab/h/l switch pane  o browser  m read  s star  r refresh  q quit"
+
+type tuiFeed struct {
+	// ID isn't valid for the "All feeds" entry
+	ID     uuid.NullUUID
+	Name   string
+	Unread int64
+}
+
+type tui struct {
+	s    *state
+	user database.User
+
+	width, height int
+	focus         int
+	status        string
+
+	feeds   []tuiFeed
+	feedIdx int
+	feedTop int
+
+	posts   []database.GetReaderItemsRow
+	postIdx int
+	postTop int
+
+	// reader is the selected post rendered for the reader pane, readerFor
+	// and readerWidth what it was rendered for
+	reader      []string
+	readerFor   uuid.UUID
+	readerWidth int
+	readerTop   int
+}
+
+func handlerTUI(s *state, cmd command, user database.User) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return fmt.Errorf("gator %s needs to run in a terminal", cmd.name)
+	}
+
+	t := &tui{s: s, user: user}
+	if err := t.load(); err != nil {
+		return err
+	}
+	t.width, t.height, _ = term.GetSize(out)
+
+	oldState, err := term.MakeRaw(in)
+	if err != nil {
+		return fmt.Errorf("Error setting up the terminal:\n%w", err)
+	}
+	os.Stdout.WriteString(ansiAltScreen + ansiClearScreen)
+	defer func() {
+		os.Stdout.WriteString(ansiMainScreen)
+		term.Restore(in, oldState)
+	}()
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+	refresh := time.NewTicker(tuiRefresh)
+	defer refresh.Stop()
+	// there's no portable resize signal, so the size is polled
+	resize := time.NewTicker(250 * time.Millisecond)
+	defer resize.Stop()
+
+	t.draw()
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok || key == "q" || key == "ctrl-c" {
+				return nil
+			}
+			t.status = ""
+			if err := t.handleKey(key); err != nil {
+				t.status = strings.ReplaceAll(err.Error(), "\n", " ")
+			}
+		case <-refresh.C:
+			if err := t.load(); err != nil {
+				t.status = strings.ReplaceAll(err.Error(), "\n", " ")
+			}
+		case <-resize.C:
+			width, height, err := term.GetSize(out)
+			if err != nil || (width == t.width && height == t.height) {
+				continue
+			}
+			t.width, t.height = width, height
+			os.Stdout.WriteString(ansiClearScreen)
+		}
+		t.draw()
+	}
+}
+
+// load reloads the feeds, unread counts and posts, keeping the same feed and
+// post selected.
+func (t *tui) load() error {
+	follows, err := t.s.db.GetFeedFollowsByUser(context.Background(), t.user.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting followed feeds:\n%w", err)
+	}
+	counts, err := t.s.db.GetUnreadCountsForUser(context.Background(), t.user.ID)
+	if err != nil {
+		return fmt.Errorf("Error getting unread counts:\n%w", err)
+	}
+	unread := map[string]int64{}
+	for _, count := range counts {
+		unread[count.FeedUrl] = count.UnreadCount
+	}
+
+	all := tuiFeed{Name: "All feeds"}
+	feeds := []tuiFeed{}
+	for _, follow := range follows {
+		feeds = append(feeds, tuiFeed{
+			ID:     uuid.NullUUID{UUID: follow.FeedID, Valid: true},
+			Name:   stripControl(follow.FeedName),
+			Unread: unread[follow.FeedUrl],
+		})
+		all.Unread += unread[follow.FeedUrl]
+	}
+	sort.Slice(feeds, func(i, j int) bool {
+		return strings.ToLower(feeds[i].Name) < strings.ToLower(feeds[j].Name)
+	})
+	feeds = append([]tuiFeed{all}, feeds...)
+
+	selected := uuid.NullUUID{}
+	if t.feedIdx < len(t.feeds) {
+		selected = t.feeds[t.feedIdx].ID
+	}
+	t.feeds = feeds
+	t.feedIdx = 0
+	for i, feed := range feeds {
+		if feed.ID == selected {
+			t.feedIdx = i
+		}
+	}
+	return t.loadPosts()
+}
+
+func (t *tui) loadPosts() error {
+	posts, err := t.s.db.GetReaderItems(context.Background(), database.GetReaderItemsParams{
+		UserID:   t.user.ID,
+		FeedID:   t.feeds[t.feedIdx].ID,
+		RowLimit: tuiPostLimit,
+	})
+	if err != nil {
+		return fmt.Errorf("Error getting posts:\n%w", err)
+	}
+	selected := uuid.Nil
+	if post, ok := t.currentPost(); ok {
+		selected = post.ID
+	}
+	t.posts = posts
+	t.postIdx = 0
+	for i, post := range posts {
+		if post.ID == selected {
+			t.postIdx = i
+		}
+	}
+	return nil
+}
+
+func (t *tui) currentPost() (database.GetReaderItemsRow, bool) {
+	if t.postIdx >= len(t.posts) {
+		return database.GetReaderItemsRow{}, false
+	}
+	return t.posts[t.postIdx], true
+}
+
+func (t *tui) handleKey(key string) error {
+	switch key {
+	case "j", "down":
+		return t.move(1)
+	case "k", "up":
+		return t.move(-1)
+	case " ", "pgdn":
+		if t.focus == paneReader {
+			t.readerTop += t.paneHeight() - 1
+		}
+	case "pgup":
+		if t.focus == paneReader {
+			t.readerTop -= t.paneHeight() - 1
+		}
+	case "\t", "l", "right":
+		t.focus = min(t.focus+1, paneReader)
+	case "h", "left", "esc", "backspace":
+		t.focus = max(t.focus-1, paneFeeds)
+	case "enter":
+		switch t.focus {
+		case paneFeeds:
+			t.focus = panePosts
+		case panePosts:
+			t.focus = paneReader
+			if post, ok := t.currentPost(); ok && !post.IsRead {
+				return t.setRead(post, true)
+			}
+		}
+	case "o":
+		post, ok := t.currentPost()
+		if !ok {
+			return nil
+		}
+		if err := openBrowser(post.Url); err != nil {
+			return err
+		}
+		t.status = "Opened " + stripControl(post.Url)
+		if !post.IsRead {
+			return t.setRead(post, true)
+		}
+	case "m":
+		if post, ok := t.currentPost(); ok {
+			return t.setRead(post, !post.IsRead)
+		}
+	case "s":
+		if post, ok := t.currentPost(); ok {
+			if err := t.s.db.SetPostStarred(context.Background(), database.SetPostStarredParams{
+				UserID:    t.user.ID,
+				PostID:    post.ID,
+				IsStarred: !post.IsStarred,
+			}); err != nil {
+				return fmt.Errorf("Error starring post:\n%w", err)
+			}
+			return t.load()
+		}
+	case "r":
+		return t.load()
+	}
+	return nil
+}
+
+func (t *tui) move(delta int) error {
+	switch t.focus {
+	case paneFeeds:
+		idx := clamp(t.feedIdx+delta, 0, len(t.feeds)-1)
+		if idx == t.feedIdx {
+			return nil
+		}
+		t.feedIdx = idx
+		t.postIdx, t.postTop = 0, 0
+		t.posts = nil
+		return t.loadPosts()
+	case panePosts:
+		t.postIdx = clamp(t.postIdx+delta, 0, len(t.posts)-1)
+	case paneReader:
+		t.readerTop += delta
+	}
+	return nil
+}
+
+func (t *tui) setRead(post database.GetReaderItemsRow, read bool) error {
+	if err := t.s.db.SetPostRead(context.Background(), database.SetPostReadParams{
+		UserID: t.user.ID,
+		PostID: post.ID,
+		IsRead: read,
+	}); err != nil {
+		return fmt.Errorf("Error marking post read:\n%w", err)
+	}
+	return t.load()
+}
+
+// paneWidths splits the screen between the panes, less a column for each of
+// the two separators.
+func (t *tui) paneWidths() (feeds, posts, reader int) {
+	feeds = clamp(t.width/5, 16, 30)
+	posts = max((t.width-feeds)*2/5, 24)
+	reader = t.width - feeds - posts - 2
+	return feeds, posts, reader
+}
+
+// paneHeight leaves a line for the title bar and one for the status line.
+func (t *tui) paneHeight() int {
+	return t.height - 2
+}
+
+func (t *tui) draw() {
+	var sb strings.Builder
+	sb.WriteString(ansiHome)
+	feedsWidth, postsWidth, readerWidth := t.paneWidths()
+	height := t.paneHeight()
+	if readerWidth < 20 || height < 3 {
+		sb.WriteString(ansiClearScreen + "Terminal too small")
+		os.Stdout.WriteString(sb.String())
+		return
+	}
+
+	feed := t.feeds[t.feedIdx]
+	title := fmt.Sprintf(" gator - %s - %s (%d unread)", t.user.Name, feed.Name, feed.Unread)
+	sb.WriteString(ansiReverse + fitLine(title, t.width) + ansiReset + "\r\n")
+
+	feedLines := t.feedLines(feedsWidth, height)
+	postLines := t.postLines(postsWidth, height)
+	readerLines := t.readerLines(readerWidth, height)
+	for row := 0; row < height; row++ {
+		sb.WriteString(feedLines[row] + "│" + postLines[row] + "│" + readerLines[row] + "\r\n")
+	}
+
+	status := t.status
+	if status == "" {
+		status = tuiHelp
+	}
+	// no newline after the last line, or the screen scrolls
+	sb.WriteString(fitLine(" "+status, t.width))
+	os.Stdout.WriteString(sb.String())
+}
+
+func (t *tui) feedLines(width, height int) []string {
+	t.feedTop = scrollTo(t.feedIdx, t.feedTop, height)
+	lines := make([]string, height)
+	for row := range lines {
+		i := t.feedTop + row
+		if i >= len(t.feeds) {
+			lines[row] = fitLine("", width)
+			continue
+		}
+		feed := t.feeds[i]
+		count := ""
+		if feed.Unread > 0 {
+			count = fmt.Sprintf(" %d", feed.Unread)
+		}
+		name := fitLine(" "+feed.Name, width-utf8.RuneCountInString(count))
+		lines[row] = t.highlight(name+count, i == t.feedIdx, paneFeeds)
+	}
+	return lines
+}
+
+func (t *tui) postLines(width, height int) []string {
+	t.postTop = scrollTo(t.postIdx, t.postTop, height)
+	lines := make([]string, height)
+	for row := range lines {
+		i := t.postTop + row
+		if i >= len(t.posts) {
+			if i == 0 {
+				lines[row] = fitLine(" No posts", width)
+			} else {
+				lines[row] = fitLine("", width)
+			}
+			continue
+		}
+		post := t.posts[i]
+		marker := " "
+		if !post.IsRead {
+			marker = "●"
+		}
+		if post.IsStarred {
+			marker += "★"
+		} else {
+			marker += " "
+		}
+		line := fmt.Sprintf("%s %s %s", marker, readerPostTime(post).Local().Format("Jan 02"), stripControl(post.Title))
+		lines[row] = t.highlight(fitLine(line, width), i == t.postIdx, panePosts)
+	}
+	return lines
+}
+
+func (t *tui) readerLines(width, height int) []string {
+	post, ok := t.currentPost()
+	if !ok {
+		t.reader = nil
+	} else if post.ID != t.readerFor || width != t.readerWidth {
+		t.reader = renderPost(post, width-1)
+		t.readerFor, t.readerWidth, t.readerTop = post.ID, width, 0
+	}
+	t.readerTop = clamp(t.readerTop, 0, max(len(t.reader)-height, 0))
+
+	lines := make([]string, height)
+	for row := range lines {
+		line := ""
+		if i := t.readerTop + row; i < len(t.reader) {
+			line = " " + t.reader[i]
+		}
+		lines[row] = fitLine(line, width)
+	}
+	return lines
+}
+
+// highlight shows the selected line in reverse video, or in bold when its
+// pane isn't focused.
+func (t *tui) highlight(line string, selected bool, pane int) string {
+	if !selected {
+		return line
+	}
+	if t.focus == pane {
+		return ansiReverse + line + ansiReset
+	}
+	return ansiBold + line + ansiReset
+}
+
+// renderPost renders a post for the reader pane, one string per line.
+func renderPost(post database.GetReaderItemsRow, width int) []string {
+	opts := renderOptions{Width: width, ANSI: os.Getenv("NO_COLOR") == ""}
+	header := "<h1>" + html.EscapeString(post.Title) + "</h1><p>" + html.EscapeString(post.FeedName)
+	if post.Author.Valid {
+		header += " - " + html.EscapeString(post.Author.String)
+	}
+	header += "<br>" + html.EscapeString(readerPostTime(post).Local().Format(time.DateTime)) + "</p>"
+	lines := strings.Split(renderHTML(header, opts), "\n")
+	lines = append(lines, stripControl(post.Url), strings.Repeat("─", max(min(width, 40), 1)), "")
+
+	body := post.FullContent.String
+	if body == "" {
+		body = post.Content.String
+	}
+	if body == "" {
+		body = post.Description.String
+	}
+	if body == "" {
+		return append(lines, "No content available")
+	}
+	return append(lines, strings.Split(renderHTML(body, opts), "\n")...)
+}
+
+// readKeys reads key presses from r, naming the special keys, until r fails.
+func readKeys(r io.Reader, keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		data := buf[:n]
+		for len(data) > 0 {
+			if data[0] == 0x1b && len(data) > 2 && data[1] == '[' {
+				// CSI sequence, up to its final byte
+				end := 2
+				for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+					end++
+				}
+				end = min(end+1, len(data))
+				keys <- keyName(string(data[:end]))
+				data = data[end:]
+				continue
+			}
+			char, size := utf8.DecodeRune(data)
+			keys <- keyName(string(char))
+			data = data[size:]
+		}
+	}
+}
+
+func keyName(seq string) string {
+	switch seq {
+	case "\x1b[A":
+		return "up"
+	case "\x1b[B":
+		return "down"
+	case "\x1b[C":
+		return "right"
+	case "\x1b[D":
+		return "left"
+	case "\x1b[5~":
+		return "pgup"
+	case "\x1b[6~":
+		return "pgdn"
+	case "\x1b":
+		return "esc"
+	case "\r", "\n":
+		return "enter"
+	case "\x7f", "\x08":
+		return "backspace"
+	case "\x03":
+		return "ctrl-c"
+	}
+	return seq
+}
+
+// sgrCode matches the styling escape codes the renderer adds, the only ones
+// fitLine lets through.
+var sgrCode = regexp.MustCompile(`^\x1b\[[0-9;]*m`)
+
+// fitLine cuts or pads line to exactly width columns, leaving its styling
+// codes alone.
+func fitLine(line string, width int) string {
+	var sb strings.Builder
+	visible, styled := 0, false
+	for i := 0; i < len(line); {
+		if line[i] == 0x1b {
+			// anything but styling is dropped, escape first, so it's shown
+			// as harmless text
+			if code := sgrCode.FindString(line[i:]); code != "" {
+				sb.WriteString(code)
+				styled = true
+				i += len(code)
+			} else {
+				i++
+			}
+			continue
+		}
+		char, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+		if visible >= width {
+			continue
+		}
+		if char < 0x20 || (char >= 0x7f && char < 0xa0) {
+			char = ' '
+		}
+		sb.WriteRune(char)
+		visible++
+	}
+	if styled {
+		sb.WriteString(ansiReset)
+	}
+	sb.WriteString(strings.Repeat(" ", max(width-visible, 0)))
+	return sb.String()
+}
+
+// scrollTo returns the first line to show so that line selected is visible.
+func scrollTo(selected, top, height int) int {
+	if selected < top {
+		return selected
+	}
+	if selected >= top+height {
+		return selected - height + 1
+	}
+	return top
+}
+
+func clamp(value, lo, hi int) int {
+	return max(lo, min(value, hi))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/thomas-reed/gator/internal/database"
+)
+
+// trackingParams are query parameters that only exist for analytics, so two
+// URLs differing only in them point at the same feed.
+var trackingParams = []string{
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+	"igshid",
+	"_hsenc",
+	"_hsmi",
+}
+
+// normalizeFeedURL puts a feed URL in the form gator stores it in: lower case
+// scheme and host, no default port, no fragment, no tracking parameters,
+// sorted query and no trailing slash (except for the root path).  A missing
+// scheme defaults to https.
+func normalizeFeedURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if i := strings.Index(rawURL, "://"); i < 0 || strings.ContainsAny(rawURL[:i], "/?#") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("Invalid URL %s:\n%w", rawURL, err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("Invalid URL %s: only http and https are supported", rawURL)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("Invalid URL %s: missing host", rawURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = host + ":" + port
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.RawQuery != "" {
+		query := u.Query()
+		for param := range query {
+			if strings.HasPrefix(strings.ToLower(param), "utm_") || isTrackingParam(param) {
+				query.Del(param)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	} else if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	}
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+func isTrackingParam(param string) bool {
+	for _, tracking := range trackingParams {
+		if strings.EqualFold(param, tracking) {
+			return true
+		}
+	}
+	return false
+}
+
+// findFeed looks a feed up by URL, treating URLs that only differ in the
+// ways normalizeFeedURL cleans up - or in http vs https - as the same feed.
+// It returns sql.ErrNoRows if there's no such feed.
+func findFeed(ctx context.Context, db *database.Queries, rawURL string) (database.Feed, error) {
+	candidates := []string{strings.TrimSpace(rawURL)}
+	if normalized, err := normalizeFeedURL(rawURL); err == nil {
+		candidates = append(candidates, normalized)
+		if rest, found := strings.CutPrefix(normalized, "https://"); found {
+			candidates = append(candidates, "http://"+rest)
+		} else if rest, found := strings.CutPrefix(normalized, "http://"); found {
+			candidates = append(candidates, "https://"+rest)
+		}
+	}
+	for _, candidate := range candidates {
+		feed, err := db.GetFeedByURL(ctx, candidate)
+		if err == nil {
+			return feed, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Feed{}, err
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+// moveFeed points feed at newURL after a permanent redirect.  If another
+// feed already lives at newURL, feed's followers and posts are merged into it
+// and feed is deleted.  It returns the feed that now owns the URL.
+func moveFeed(s *state, feed database.Feed, newURL string) (database.Feed, error) {
+	newURL, err := normalizeFeedURL(newURL)
+	if err != nil {
+		return feed, err
+	}
+	if current, err := normalizeFeedURL(feed.Url); err == nil && current == newURL {
+		return feed, nil
+	}
+
+	existing, err := findFeed(context.Background(), s.db, newURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		moved, err := s.db.SetFeedURL(context.Background(), database.SetFeedURLParams{
+			ID:  feed.ID,
+			Url: newURL,
+		})
+		if err != nil {
+			return feed, fmt.Errorf("Error updating feed URL:\n%w", err)
+		}
+		fmt.Printf("Feed %s moved permanently to %s\n", feed.Url, stripControl(moved.Url))
+		return moved, nil
+	}
+	if err != nil {
+		return feed, fmt.Errorf("Error getting feed from db:\n%w", err)
+	}
+	if existing.ID == feed.ID {
+		return feed, nil
+	}
+
+	tx, err := s.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return feed, fmt.Errorf("Error starting transaction:\n%w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+	if err := qtx.MoveFeedFollows(context.Background(), database.MoveFeedFollowsParams{
+		ToFeedID:   existing.ID,
+		FromFeedID: feed.ID,
+	}); err != nil {
+		return feed, fmt.Errorf("Error moving feed follows:\n%w", err)
+	}
+	if err := qtx.MovePosts(context.Background(), database.MovePostsParams{
+		ToFeedID:   existing.ID,
+		FromFeedID: feed.ID,
+	}); err != nil {
+		return feed, fmt.Errorf("Error moving posts:\n%w", err)
+	}
+	if err := qtx.MoveFilters(context.Background(), database.MoveFiltersParams{
+		ToFeedID:   uuid.NullUUID{UUID: existing.ID, Valid: true},
+		FromFeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	}); err != nil {
+		return feed, fmt.Errorf("Error moving filters:\n%w", err)
+	}
+	if err := qtx.MoveRules(context.Background(), database.MoveRulesParams{
+		ToFeedID:   uuid.NullUUID{UUID: existing.ID, Valid: true},
+		FromFeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	}); err != nil {
+		return feed, fmt.Errorf("Error moving rules:\n%w", err)
+	}
+	if err := qtx.DeleteFeed(context.Background(), feed.ID); err != nil {
+		return feed, fmt.Errorf("Error deleting duplicate feed:\n%w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return feed, fmt.Errorf("Error committing transaction:\n%w", err)
+	}
+	fmt.Printf("Feed %s moved permanently to %s - merged into existing feed '%s'\n", feed.Url, stripControl(existing.Url), stripControl(existing.Name))
+	return existing, nil
+}